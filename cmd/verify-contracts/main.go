@@ -0,0 +1,57 @@
+// Command verify-contracts replays consumer contracts against a running
+// server and exits non-zero if any interaction fails.
+//
+//	verify-contracts [-base-url http://localhost:8080] [-token TOKEN] [path ...]
+//
+// Each path is a contract file or a directory of them; the default is the
+// contracts directory.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"backendGo/internal/contract"
+)
+
+func main() {
+	baseURL := flag.String("base-url", "http://localhost:8080", "address of the server under test")
+	token := flag.String("token", os.Getenv("CARRENTAL_VERIFY_TOKEN"), "bearer token for authenticated requests (env CARRENTAL_VERIFY_TOKEN)")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each request")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"contracts"}
+	}
+	contracts, err := contract.LoadAll(paths...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Loading contracts:", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	v := &contract.Verifier{BaseURL: *baseURL, Token: *token, Client: &http.Client{Timeout: *timeout}}
+	failed := 0
+	for _, c := range contracts {
+		for _, r := range v.Verify(ctx, c) {
+			if r.Err != nil {
+				failed++
+				fmt.Printf("FAIL %s: %s: %v\n", r.Consumer, r.Description, r.Err)
+			} else {
+				fmt.Printf("ok   %s: %s\n", r.Consumer, r.Description)
+			}
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d interaction(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,441 @@
+// Package api exposes the rental service over HTTP.
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Services are the application services the API is built on.
+type Services struct {
+	Rentals service.RentalService
+	Changes service.ChangeService
+	Health  service.HealthService
+	Auth    service.AuthService
+	Keys    service.KeyService
+	// Cleaning runs the cleaning step between a return and the next
+	// rental.
+	Cleaning service.CleaningService
+	// Categories manages the classes of cars that customers book.
+	Categories service.CategoryService
+	// OIDC, if set, enables staff login through an OIDC provider.
+	OIDC service.OIDCService
+	// Deprecations records use of deprecated endpoints and fields.
+	Deprecations service.DeprecationService
+}
+
+// Config tunes the HTTP layer. The zero Config applies no limits.
+type Config struct {
+	RateLimit RateLimit
+	CORS      CORS
+	// HandlerTimeout bounds how long a request may take to serve.
+	HandlerTimeout time.Duration
+	// MaxBodyBytes bounds the size of request bodies.
+	MaxBodyBytes int64
+}
+
+// Handler serves the HTTP API.
+type Handler struct {
+	rentals    service.RentalService
+	changes    service.ChangeService
+	health     service.HealthService
+	auth       service.AuthService
+	keys       service.KeyService
+	cleaning   service.CleaningService
+	categories service.CategoryService
+	oidc       service.OIDCService
+	config     Config
+	// limiter is nil when rate limiting is disabled.
+	limiter *rateLimiter
+
+	deprecationUsage service.DeprecationService
+	deprecations     map[string]deprecation
+
+	// draining is closed by Drain to end pending long polls.
+	draining  chan struct{}
+	drainOnce sync.Once
+}
+
+// NewHandler returns a Handler serving s.
+func NewHandler(s Services, cfg Config) *Handler {
+	h := &Handler{
+		rentals:          s.Rentals,
+		changes:          s.Changes,
+		health:           s.Health,
+		auth:             s.Auth,
+		keys:             s.Keys,
+		cleaning:         s.Cleaning,
+		categories:       s.Categories,
+		oidc:             s.OIDC,
+		config:           cfg,
+		deprecationUsage: s.Deprecations,
+		deprecations:     make(map[string]deprecation),
+		draining:         make(chan struct{}),
+	}
+	if cfg.RateLimit.RequestsPerSecond > 0 {
+		h.limiter = newRateLimiter(cfg.RateLimit)
+	}
+	return h
+}
+
+// Drain answers pending long polls at once, so that a graceful shutdown
+// does not wait for them to time out. Register it with
+// http.Server.RegisterOnShutdown.
+func (h *Handler) Drain() {
+	h.drainOnce.Do(func() { close(h.draining) })
+}
+
+// Router returns the HTTP handler with all API routes registered.
+func (h *Handler) Router() http.Handler {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(notFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+	r.Use(traceRequests, instrument, negotiateVersion, h.limitDuration, h.limitBody, h.limitRate, h.authenticate, h.limitUsers)
+
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	r.HandleFunc("/healthz", h.healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.readyz).Methods("GET")
+	// Listing deleted cars is for admins only.
+	r.HandleFunc("/cars", h.requireRole(h.listAvailableCars, service.RoleAdmin)).Methods("GET").Queries("include_deleted", "{include_deleted}")
+	r.HandleFunc("/cars", h.listAvailableCars).Methods("GET")
+	r.HandleFunc("/cars", h.requireRole(h.addCar, service.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/cars/availability/poll", h.pollAvailability).Methods("GET")
+	r.HandleFunc("/cars/search", h.requireRole(h.searchCars, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/cars/{registration}", h.requireRole(h.getCar, service.RoleAdmin)).Methods("GET").Queries("include_deleted", "{include_deleted}")
+	r.HandleFunc("/cars/{registration}", h.getCar).Methods("GET")
+	r.HandleFunc("/cars/{registration}", h.requireRole(h.updateCar, service.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/cars/{registration}", h.requireRole(h.patchCar, service.RoleAdmin)).Methods("PATCH")
+	r.HandleFunc("/cars/{registration}", h.requireRole(h.deleteCar, service.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/cars/{registration}/restoration", h.requireRole(h.restoreCar, service.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/cars/{registration}/rentals", h.requireRole(h.rentCar, service.RoleAdmin, service.RoleAgent)).Methods("POST")
+	r.HandleFunc("/cars/{registration}/returns", h.requireRole(h.returnCar, service.RoleAdmin, service.RoleAgent)).Methods("POST")
+	r.HandleFunc("/cars/{registration}/keys", h.requireRole(h.listCarKeys, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/cars/{registration}/keys", h.requireRole(h.addCarKey, service.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/keys/alerts", h.requireRole(h.listKeyAlerts, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/keys/{id}/handovers", h.requireRole(h.listKeyHandovers, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/keys/{id}/handovers", h.requireRole(h.handOverKey, service.RoleAdmin, service.RoleAgent)).Methods("POST")
+	r.HandleFunc("/cleaning/tasks", h.requireRole(h.listCleaningTasks, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/cleaning/tasks/{id:[0-9]+}/assignee", h.requireRole(h.assignCleaningTask, service.RoleAdmin, service.RoleAgent)).Methods("PUT")
+	r.HandleFunc("/cleaning/tasks/{id:[0-9]+}/completion", h.requireRole(h.completeCleaningTask, service.RoleAdmin, service.RoleAgent)).Methods("POST")
+	r.HandleFunc("/categories", h.listCategories).Methods("GET")
+	r.HandleFunc("/categories", h.requireRole(h.addCategory, service.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/categories/availability", h.categoryAvailability).Methods("GET")
+	r.HandleFunc("/categories/{name}", h.requireRole(h.updateCategory, service.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/categories/{name}", h.requireRole(h.deleteCategory, service.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/changes", h.requireRole(h.listChanges, service.RoleAdmin, service.RoleAgent)).Methods("GET")
+	r.HandleFunc("/auth/login", h.login).Methods("POST")
+	r.HandleFunc("/auth/refresh", h.refresh).Methods("POST")
+	r.HandleFunc("/auth/logout", h.requireRole(h.logout, allRoles...)).Methods("POST")
+	r.HandleFunc("/auth/sessions", h.requireRole(h.listSessions, allRoles...)).Methods("GET")
+	r.HandleFunc("/auth/sessions/{id}", h.requireRole(h.revokeSession, allRoles...)).Methods("DELETE")
+	if h.oidc != nil {
+		r.HandleFunc("/auth/oidc/login", h.oidcLogin).Methods("GET")
+		r.HandleFunc("/auth/oidc/callback", h.oidcCallback).Methods("GET")
+	}
+	r.HandleFunc("/users", h.requireRole(h.listUsers, service.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/users", h.requireRole(h.createUser, service.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/users/{username}/role", h.requireRole(h.setUserRole, service.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/users/{username}/sessions", h.requireRole(h.revokeUserSessions, service.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/users/{username}/disabled", h.requireRole(h.disableUser, service.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/users/{username}/disabled", h.requireRole(h.enableUser, service.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/deprecations", h.requireRole(h.deprecationReport, service.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/schemas", h.listSchemas).Methods("GET")
+	r.HandleFunc("/schemas/{name}", h.getSchema).Methods("GET")
+
+	// Logging wraps the router rather than being router middleware so that
+	// unmatched routes are logged too.
+	return logRequests(h.cors(r))
+}
+
+func (h *Handler) listAvailableCars(w http.ResponseWriter, r *http.Request) {
+	include, ok := includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	q := store.CarQuery{IncludeDeleted: include}
+	if !readPage(w, r, &q) || !readFilter(w, r, &q) {
+		return
+	}
+
+	page, err := h.rentals.ListAvailable(r.Context(), q)
+	if err != nil {
+		logger(r).Error("Error querying data", "error", err)                                                      // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve available cars") // Return appropriate HTTP status code
+		return
+	}
+	writePageHeaders(w, r, q, page)
+
+	// Encode and send response
+	if err := encodeResponse(w, r, page.Cars); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) getCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+	include, ok := includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	car, err := h.rentals.Car(r.Context(), registration, include)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error querying data", "error", err)                                           // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve car") // Return appropriate HTTP status code
+		return
+	}
+
+	w.Header().Set("ETag", carETag(car))
+	if err := encodeResponse(w, r, car); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// includeDeleted reads the include_deleted query parameter. The routes only
+// let admins send it.
+func includeDeleted(w http.ResponseWriter, r *http.Request) (include, ok bool) {
+	v := r.URL.Query().Get("include_deleted")
+	if v == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		logger(r).Info("Invalid include_deleted", "error", err)                                   // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid include_deleted") // Return appropriate HTTP status code
+		return false, false
+	}
+	return include, true
+}
+
+func (h *Handler) addCar(w http.ResponseWriter, r *http.Request) {
+	var newCar store.Car
+	if !decodeJSON(w, r, &newCar) {
+		return
+	}
+
+	err := h.rentals.AddCar(r.Context(), newCar)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid car", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The car is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error inserting data", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to add car") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Car added successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// updateCar replaces the model, mileage and vehicle details of a car. The
+// body is a whole car, so details left out become unknown; its
+// registration may be left out but not changed, and rented and
+// needs_cleaning are ignored. With If-Match, the car is only replaced if
+// it has not changed since the client read it.
+func (h *Handler) updateCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var car store.Car
+	if !decodeJSON(w, r, &car) {
+		return
+	}
+	if car.Registration != "" && car.Registration != registration {
+		logger(r).Info("Registration change refused", "registration", registration, "new_registration", car.Registration) // Log detailed error information
+		writeValidationProblem(w, r, "The car is invalid", []service.FieldError{
+			{Field: "registration", Message: "cannot be changed"},
+		}) // Return appropriate HTTP status code
+		return
+	}
+	car.Registration, car.Version = registration, version
+
+	updated, err := h.rentals.UpdateCar(r.Context(), car)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid car", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The car is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarModified):
+		writeCarModified(w, r, registration)
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update car") // Return appropriate HTTP status code
+		return
+	}
+
+	w.Header().Set("ETag", carETag(updated))
+	if err := encodeResponse(w, r, updated); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) rentCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+
+	err := h.rentals.Rent(r.Context(), registration)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarAlreadyRented):
+		logger(r).Info("Car is already rented", "registration", registration)                    // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "car_already_rented", "Car is already rented") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNeedsCleaning):
+		logger(r).Info("Car needs cleaning", "registration", registration)                              // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "car_needs_cleaning", "Car is waiting to be cleaned") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                                   // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update car rental status") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Car rented successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) returnCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+
+	// If there's a mileage parameter in the request, add it to the car's mileage
+	var mileage int
+	if mileageStr := r.URL.Query().Get("mileage"); mileageStr != "" {
+		var err error
+		mileage, err = strconv.Atoi(mileageStr)
+		if err != nil {
+			logger(r).Info("Invalid mileage", "error", err)                                   // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid mileage") // Return appropriate HTTP status code
+			return
+		}
+	}
+
+	err := h.rentals.Return(r.Context(), registration, mileage)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid return", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The return is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotRented):
+		logger(r).Info("Car was not rented", "registration", registration)                // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "car_not_rented", "Car was not rented") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update car data") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Car returned successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// deleteCar takes a car out of the fleet without erasing its history; see
+// restoreCar. If-Match works as for updateCar.
+func (h *Handler) deleteCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.rentals.DeleteCar(r.Context(), registration, version)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarAlreadyRented):
+		logger(r).Info("Car is rented", "registration", registration)                                               // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "car_already_rented", "Car is rented; return it before deleting") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarModified):
+		writeCarModified(w, r, registration)
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to delete car") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Car deleted successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) restoreCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+
+	car, err := h.rentals.RestoreCar(r.Context(), registration)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotDeleted):
+		logger(r).Info("Car is not deleted", "registration", registration)                 // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "car_not_deleted", "Car is not deleted") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                      // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to restore car") // Return appropriate HTTP status code
+		return
+	}
+
+	w.Header().Set("ETag", carETag(car))
+	if err := encodeResponse(w, r, car); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
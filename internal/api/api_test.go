@@ -0,0 +1,552 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+var testAuthConfig = service.AuthConfig{SigningKey: []byte("secret"), TokenTTL: time.Hour, RefreshTTL: 24 * time.Hour}
+
+// newTestRouter returns a router over a real service and a fresh in-memory
+// database.
+func newTestRouter(t *testing.T) (http.Handler, *store.SQLRepository) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Each connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	cars, err := store.NewSQLRepository(db, "sqlite")
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+	if err := cars.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewHandler(Services{
+		Rentals:      service.NewRentalService(cars),
+		Changes:      service.NewChangeService(cars),
+		Health:       service.NewHealthService(cars),
+		Auth:         fakeAuth{service.NewAuthService(cars, cars, testAuthConfig)},
+		Keys:         service.NewKeyService(cars, cars),
+		Cleaning:     service.NewCleaningService(cars, time.Hour),
+		Categories:   service.NewCategoryService(cars),
+		Deprecations: service.NewDeprecationService(cars),
+	}, Config{}).Router(), cars
+}
+
+// Bearer tokens accepted by fakeAuth, one per role.
+const (
+	testToken     = "admin-token"
+	agentToken    = "agent-token"
+	customerToken = "customer-token"
+)
+
+// fakeAuth accepts fixed tokens instead of JWTs and leaves everything else
+// to the embedded AuthService.
+type fakeAuth struct {
+	service.AuthService
+}
+
+func (fakeAuth) Authenticate(_ context.Context, token string) (service.Principal, error) {
+	switch token {
+	case testToken:
+		return service.Principal{Username: "tester", Role: service.RoleAdmin}, nil
+	case agentToken:
+		return service.Principal{Username: "agent", Role: service.RoleAgent}, nil
+	case customerToken:
+		return service.Principal{Username: "customer", Role: service.RoleCustomer}, nil
+	}
+	return service.Principal{}, service.ErrInvalidToken
+}
+
+// doRequest serves a request authenticated as an admin.
+func doRequest(t *testing.T, router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func availableCars(t *testing.T, router http.Handler) []store.Car {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodGet, "/cars", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /cars: status %d", rec.Code)
+	}
+	var cars []store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil {
+		t.Fatalf("decode cars: %v", err)
+	}
+	return cars
+}
+
+func TestAddRentReturnFlow(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("add car: status %d: %s", rec.Code, rec.Body)
+	}
+	if cars := availableCars(t, router); len(cars) != 1 || cars[0].Registration != "DEF456" {
+		t.Fatalf("available after add = %+v, want DEF456", cars)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rent car: status %d: %s", rec.Code, rec.Body)
+	}
+	if cars := availableCars(t, router); len(cars) != 0 {
+		t.Fatalf("available after rent = %+v, want none", cars)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("rent rented car: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cars/DEF456/returns?mileage=150", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("return car: status %d: %s", rec.Code, rec.Body)
+	}
+	if cars := availableCars(t, router); len(cars) != 0 {
+		t.Fatalf("available before cleaning = %+v, want none", cars)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "car_needs_cleaning") {
+		t.Fatalf("rent uncleaned car: status %d: %s, want %d car_needs_cleaning", rec.Code, rec.Body, http.StatusBadRequest)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cleaning/tasks/1/completion", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("complete cleaning: status %d: %s", rec.Code, rec.Body)
+	}
+	cars := availableCars(t, router)
+	if len(cars) != 1 || cars[0].Mileage != 3350 {
+		t.Fatalf("available after return = %+v, want DEF456 with mileage 3350", cars)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cars/DEF456/returns", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("return available car: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRentReturnUnknownCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, target := range []string{"/cars/NOPE/rentals", "/cars/NOPE/returns"} {
+		if rec := doRequest(t, router, http.MethodPost, target, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("POST %s: status %d, want %d", target, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestReturnInvalidMileage(t *testing.T) {
+	router, cars := newTestRouter(t)
+
+	if err := cars.Add(context.Background(), store.Car{Model: "Tesla M3", Registration: "BTS812", Mileage: 6003, Rented: true}); err != nil {
+		t.Fatalf("add car: %v", err)
+	}
+	rec := doRequest(t, router, http.MethodPost, "/cars/BTS812/returns?mileage=abc", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unparsable mileage: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = doRequest(t, router, http.MethodPost, "/cars/BTS812/returns?mileage=-500", "")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("negative mileage: status %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+	}
+	var p problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Code != "validation_failed" || len(p.Errors) != 1 || p.Errors[0].Field != "mileage" {
+		t.Fatalf("problem = %+v, want validation_failed on mileage", p)
+	}
+	if car, err := cars.Get(context.Background(), "BTS812"); err != nil || car.Mileage != 6003 || !car.Rented {
+		t.Fatalf("car = %+v, %v, want it still rented with mileage 6003", car, err)
+	}
+}
+
+// fakeRentals is a RentalService returning canned errors.
+type fakeRentals struct {
+	service.RentalService
+	err error
+}
+
+func (f fakeRentals) Rent(context.Context, string) error        { return f.err }
+func (f fakeRentals) Return(context.Context, string, int) error { return f.err }
+
+func TestRentalErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		target string
+		want   int
+	}{
+		{nil, "/cars/X/rentals", http.StatusOK},
+		{service.ErrCarNotFound, "/cars/X/rentals", http.StatusNotFound},
+		{service.ErrCarAlreadyRented, "/cars/X/rentals", http.StatusBadRequest},
+		{service.ErrCarNeedsCleaning, "/cars/X/rentals", http.StatusBadRequest},
+		{errors.New("boom"), "/cars/X/rentals", http.StatusInternalServerError},
+		{nil, "/cars/X/returns", http.StatusOK},
+		{service.ErrCarNotFound, "/cars/X/returns", http.StatusNotFound},
+		{service.ErrCarNotRented, "/cars/X/returns", http.StatusBadRequest},
+		{errors.New("boom"), "/cars/X/returns", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		router := NewHandler(Services{Rentals: fakeRentals{err: tt.err}, Auth: fakeAuth{}}, Config{}).Router()
+		if rec := doRequest(t, router, http.MethodPost, tt.target, ""); rec.Code != tt.want {
+			t.Errorf("POST %s with %v: status %d, want %d", tt.target, tt.err, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestAddInvalidCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodPost, "/cars", `{}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+	}
+	var p problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	var fields []string
+	for _, f := range p.Errors {
+		fields = append(fields, f.Field)
+	}
+	if p.Code != "validation_failed" || strings.Join(fields, ",") != "model,registration" {
+		t.Fatalf("problem = %+v, want validation_failed on model and registration", p)
+	}
+	if cars := availableCars(t, router); len(cars) != 0 {
+		t.Fatalf("invalid car was added: %+v", cars)
+	}
+}
+
+func TestGetCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+
+	rec := doRequest(t, router, http.MethodGet, "/cars/DEF456", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var car store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&car); err != nil {
+		t.Fatalf("decode car: %v", err)
+	}
+	if want := (store.Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200, Rented: true}); car != want {
+		t.Fatalf("got %+v, want %+v", car, want)
+	}
+
+	if rec := doRequest(t, router, http.MethodGet, "/cars/NOPE", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown car: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUpdateCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+
+	rec := doRequest(t, router, http.MethodPut, "/cars/DEF456", `{"model":"Honda Civic Tourer","mileage":3150,"rented":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var car store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&car); err != nil {
+		t.Fatalf("decode car: %v", err)
+	}
+	if want := (store.Car{Model: "Honda Civic Tourer", Registration: "DEF456", Mileage: 3150, Rented: true}); car != want {
+		t.Fatalf("got %+v, want %+v", car, want)
+	}
+
+	tests := []struct {
+		target, body string
+		want         int
+	}{
+		{"/cars/DEF456", `{"model":"Honda Civic","registration":"XYZ999","mileage":3150}`, http.StatusUnprocessableEntity},
+		{"/cars/DEF456", `{"model":"","mileage":3150}`, http.StatusUnprocessableEntity},
+		{"/cars/NOPE", `{"model":"Honda Civic","mileage":3150}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(t, router, http.MethodPut, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("PUT %s %s: status %d, want %d", tt.target, tt.body, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestDeleteAndRestoreCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Tesla M3","registration":"BTS812","mileage":6003}`)
+	doRequest(t, router, http.MethodPost, "/cars/BTS812/rentals", "")
+
+	if rec := doRequest(t, router, http.MethodDelete, "/cars/BTS812", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete rented car: status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if rec := doRequest(t, router, http.MethodDelete, "/cars/DEF456", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete car: status %d: %s", rec.Code, rec.Body)
+	}
+	if cars := availableCars(t, router); len(cars) != 0 {
+		t.Fatalf("available after delete = %+v, want none", cars)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/cars?include_deleted=true", "")
+	var cars []store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil || len(cars) != 1 || cars[0].DeletedAt == nil {
+		t.Fatalf("GET /cars?include_deleted=true = %+v (%v), want deleted DEF456", cars, err)
+	}
+
+	tests := []struct {
+		token, method, target string
+		want                  int
+	}{
+		{testToken, http.MethodGet, "/cars/DEF456", http.StatusNotFound},
+		{testToken, http.MethodGet, "/cars/DEF456?include_deleted=true", http.StatusOK},
+		{testToken, http.MethodGet, "/cars/DEF456?include_deleted=maybe", http.StatusBadRequest},
+		{agentToken, http.MethodGet, "/cars?include_deleted=true", http.StatusForbidden},
+		{agentToken, http.MethodDelete, "/cars/BTS812", http.StatusForbidden},
+		{testToken, http.MethodDelete, "/cars/DEF456", http.StatusNotFound},
+		{testToken, http.MethodPost, "/cars/DEF456/rentals", http.StatusNotFound},
+		{testToken, http.MethodPost, "/cars/BTS812/restoration", http.StatusBadRequest},
+		{testToken, http.MethodPost, "/cars/NOPE/restoration", http.StatusNotFound},
+		{testToken, http.MethodPost, "/cars/DEF456/restoration", http.StatusOK},
+		{testToken, http.MethodGet, "/cars/DEF456", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s as %s: status %d, want %d: %s", tt.method, tt.target, tt.token, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if cars := availableCars(t, router); len(cars) != 1 || cars[0].Registration != "DEF456" {
+		t.Fatalf("available after restore = %+v, want DEF456", cars)
+	}
+}
+
+func TestListCarsPages(t *testing.T) {
+	router, _ := newTestRouter(t)
+	for _, reg := range []string{"AAA111", "BBB222", "CCC333"} {
+		doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"`+reg+`","mileage":3200}`)
+	}
+
+	rec := doRequest(t, router, http.MethodGet, "/cars?limit=1&offset=1", "")
+	var cars []store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil || len(cars) != 1 || cars[0].Registration != "BBB222" {
+		t.Fatalf("second page = %+v (%v), want BBB222", cars, err)
+	}
+	if got := rec.Header().Get(totalCountHeader); got != "3" {
+		t.Errorf("%s = %q, want 3", totalCountHeader, got)
+	}
+	wantLinks := []string{`</cars?limit=1&offset=2>; rel="next"`, `</cars?limit=1&offset=0>; rel="prev"`}
+	if got := rec.Header().Values("Link"); strings.Join(got, ", ") != strings.Join(wantLinks, ", ") {
+		t.Errorf("Link = %q, want %q", got, wantLinks)
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/cars", "")
+	if got := rec.Header().Get(totalCountHeader); got != "3" || rec.Header().Get("Link") != "" {
+		t.Errorf("first default page: %s %q, Link %q; want 3 and no links", totalCountHeader, got, rec.Header().Get("Link"))
+	}
+
+	// Previous pages are a page size back, as served rather than as asked.
+	for target, want := range map[string]string{
+		"/cars?offset=150":             `</cars?offset=50>; rel="prev"`,
+		"/cars?limit=5000&offset=1500": `</cars?limit=5000&offset=500>; rel="prev"`,
+	} {
+		if got := doRequest(t, router, http.MethodGet, target, "").Header().Get("Link"); got != want {
+			t.Errorf("GET %s: Link = %q, want %q", target, got, want)
+		}
+	}
+
+	for _, target := range []string{"/cars?limit=-1", "/cars?offset=x"} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestListCarsFilters(t *testing.T) {
+	router, _ := newTestRouter(t)
+	for _, car := range []string{
+		`{"model":"Tesla Model 3","registration":"AAA111","mileage":42000}`,
+		`{"model":"Honda Civic","registration":"BBB222","mileage":3200}`,
+		`{"model":"Tesla Model Y","registration":"CCC333","mileage":12000}`,
+		`{"model":"Tesla Model S","registration":"DDD444","mileage":80000}`,
+	} {
+		doRequest(t, router, http.MethodPost, "/cars", car)
+	}
+	doRequest(t, router, http.MethodPost, "/cars/BBB222/rentals", "")
+
+	rec := doRequest(t, router, http.MethodGet, "/cars?rented=false&model=tesla&min_mileage=0&max_mileage=50000&sort=mileage:desc", "")
+	var cars []store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, car := range cars {
+		got = append(got, car.Registration)
+	}
+	if strings.Join(got, " ") != "AAA111 CCC333" || rec.Header().Get(totalCountHeader) != "2" {
+		t.Errorf("filtered listing = %v, %s %q; want AAA111 CCC333 of 2", got, totalCountHeader, rec.Header().Get(totalCountHeader))
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/cars?rented=true", "")
+	cars = nil
+	if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil {
+		t.Fatal(err)
+	}
+	if len(cars) != 1 || cars[0].Registration != "BBB222" || rec.Header().Get(totalCountHeader) != "1" {
+		t.Errorf("rented listing = %+v, %s %q; want BBB222 of 1", cars, totalCountHeader, rec.Header().Get(totalCountHeader))
+	}
+
+	rec = doRequest(t, router, http.MethodGet, "/cars?sort=mileage&limit=1", "")
+	if rec.Header().Get(nextCursorHeader) != "" || !strings.Contains(rec.Header().Get("Link"), "offset=1") {
+		t.Errorf("sorted page: %s %q, Link %q; want no cursor and an offset link", nextCursorHeader, rec.Header().Get(nextCursorHeader), rec.Header().Get("Link"))
+	}
+
+	for _, target := range []string{
+		"/cars?rented=maybe",
+		"/cars?min_mileage=-1",
+		"/cars?max_mileage=x",
+		"/cars?min_mileage=10&max_mileage=5",
+		"/cars?sort=price",
+		"/cars?sort=mileage:up",
+		"/cars?sort=mileage&cursor=" + encodeCursor("AAA111"),
+	} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestSearchCars(t *testing.T) {
+	router, _ := newTestRouter(t)
+	for _, car := range []string{
+		`{"model":"Toyota Corolla Blue","registration":"ABC123","mileage":3200}`,
+		`{"model":"Toyota Corolla","registration":"BLU442","mileage":3200}`,
+		`{"model":"Honda Civic Blue","registration":"DEF456","mileage":3200}`,
+	} {
+		doRequest(t, router, http.MethodPost, "/cars", car)
+	}
+	doRequest(t, router, http.MethodPost, "/cars/ABC123/rentals", "")
+
+	search := func(target string) []string {
+		t.Helper()
+		rec := doRequest(t, router, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		var cars []store.Car
+		if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil {
+			t.Fatal(err)
+		}
+		regs := []string{}
+		for _, car := range cars {
+			regs = append(regs, car.Registration)
+		}
+		return regs
+	}
+
+	// Rented cars are found too; a registration hit ranks first.
+	if got := search("/cars/search?q=blue+corolla"); strings.Join(got, " ") != "ABC123" {
+		t.Errorf("blue corolla = %v, want ABC123", got)
+	}
+	if got := search("/cars/search?q=blu+coro"); strings.Join(got, " ") != "BLU442 ABC123" {
+		t.Errorf("blu coro = %v, want BLU442 ABC123", got)
+	}
+	if got := search("/cars/search?q=Blue&limit=1"); len(got) != 1 {
+		t.Errorf("limited search = %v, want one car", got)
+	}
+	if got := search(`/cars/search?q=%22*`); len(got) != 0 {
+		t.Errorf("punctuation search = %v, want none", got)
+	}
+
+	doRequest(t, router, http.MethodPut, "/cars/DEF456", `{"model":"Honda Jazz","mileage":3200}`)
+	doRequest(t, router, http.MethodDelete, "/cars/BLU442", "")
+	if got := search("/cars/search?q=blue"); strings.Join(got, " ") != "ABC123" {
+		t.Errorf("blue after edit and delete = %v, want ABC123", got)
+	}
+
+	for target, want := range map[string]int{
+		"/cars/search":                http.StatusUnprocessableEntity,
+		"/cars/search?q=+":            http.StatusUnprocessableEntity,
+		"/cars/search?q=blue&limit=0": http.StatusBadRequest,
+	} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != want {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, want)
+		}
+	}
+}
+
+func TestListCarsCursor(t *testing.T) {
+	router, _ := newTestRouter(t)
+	for _, reg := range []string{"BBB222", "DDD444", "FFF666"} {
+		doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"`+reg+`","mileage":3200}`)
+	}
+
+	// Cars added mid-scan before the cursor are not seen, and those after
+	// it are, without shifting the cars already returned.
+	var seen []string
+	target := "/cars?limit=2"
+	for page := 0; target != ""; page++ {
+		rec := doRequest(t, router, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d, want %d", target, rec.Code, http.StatusOK)
+		}
+		var cars []store.Car
+		if err := json.NewDecoder(rec.Body).Decode(&cars); err != nil {
+			t.Fatal(err)
+		}
+		for _, car := range cars {
+			seen = append(seen, car.Registration)
+		}
+		if page == 0 {
+			for _, reg := range []string{"AAA111", "EEE555"} {
+				doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"`+reg+`","mileage":3200}`)
+			}
+		}
+
+		target = ""
+		if cursor := rec.Header().Get(nextCursorHeader); cursor != "" {
+			target = "/cars?cursor=" + cursor + "&limit=2"
+			want := `<` + target + `>; rel="next"`
+			if got := rec.Header().Get("Link"); page > 0 && got != want {
+				t.Errorf("Link = %q, want %q", got, want)
+			}
+		}
+	}
+	if want := []string{"BBB222", "DDD444", "EEE555", "FFF666"}; strings.Join(seen, " ") != strings.Join(want, " ") {
+		t.Errorf("scrolled through %v, want %v", seen, want)
+	}
+
+	for _, target := range []string{"/cars?cursor=!", "/cars?cursor=" + encodeCursor("BBB222") + "&offset=1"} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
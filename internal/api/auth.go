@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"backendGo/internal/service"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// tokenResponse follows the OAuth 2.0 access token response (RFC 6749,
+// section 5.1).
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	// RefreshToken renews the access token through POST /auth/refresh.
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	token, err := h.auth.Login(r.Context(), req.Username, req.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		logger(r).Info("Login failed", "username", req.Username)                                           // Log detailed error information
+		writeProblem(w, r, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrUserDisabled):
+		logger(r).Info("Login by disabled user", "username", req.Username)                    // Log detailed error information
+		writeProblem(w, r, http.StatusForbidden, "user_disabled", "This account is disabled") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error issuing token", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to log in") // Return appropriate HTTP status code
+		return
+	}
+
+	writeToken(w, r, token)
+}
+
+// refresh rotates a refresh token. The old one stops working, and
+// presenting it again ends the session.
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	token, err := h.auth.Refresh(r.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, service.ErrInvalidToken):
+		logger(r).Info("Refresh rejected", "error", err)                                                         // Log detailed error information
+		writeProblem(w, r, http.StatusUnauthorized, "invalid_refresh_token", "Invalid or expired refresh token") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error refreshing token", "error", err)                                         // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to refresh token") // Return appropriate HTTP status code
+		return
+	}
+
+	writeToken(w, r, token)
+}
+
+func writeToken(w http.ResponseWriter, r *http.Request, token service.Token) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	resp := tokenResponse{
+		AccessToken:  token.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(token.ExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken: token.RefreshToken,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)
+	}
+}
+
+// allRoles lets any authenticated user through requireRole.
+var allRoles = []service.Role{service.RoleAdmin, service.RoleAgent, service.RoleCustomer}
+
+type authKey struct{}
+
+// authResult is the outcome of authenticate for a request.
+type authResult struct {
+	principal service.Principal
+	// err is errNoToken if the request carried no bearer token.
+	err error
+}
+
+var errNoToken = errors.New("no bearer token")
+
+// principal returns the authenticated caller of the request.
+func principal(r *http.Request) (service.Principal, bool) {
+	res, ok := r.Context().Value(authKey{}).(authResult)
+	return res.principal, ok && res.err == nil
+}
+
+// authenticate validates the bearer token of every request, if any, so that
+// middleware such as limitUsers knows the caller. It rejects nothing;
+// requireRole does.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := authResult{err: errNoToken}
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && token != "" && h.auth != nil {
+			res.principal, res.err = h.auth.Authenticate(r.Context(), token)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authKey{}, res)))
+	})
+}
+
+// requireRole rejects requests without a valid bearer token (401) or whose
+// principal has none of roles (403). It tags the request logger with the
+// authenticated user.
+func (h *Handler) requireRole(next http.HandlerFunc, roles ...service.Role) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, _ := r.Context().Value(authKey{}).(authResult)
+		switch {
+		case res.err == nil:
+		case errors.Is(res.err, errNoToken):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="carrental"`)
+			writeProblem(w, r, http.StatusUnauthorized, "authentication_required", "Authentication required")
+			return
+		default:
+			logger(r).Info("Rejected access token", "error", res.err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="carrental", error="invalid_token"`)
+			writeProblem(w, r, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
+			return
+		}
+
+		p := res.principal
+		l := logger(r).With("user", p.Username)
+		if !slices.Contains(roles, p.Role) {
+			l.Info("Forbidden", "role", p.Role)
+			writeProblem(w, r, http.StatusForbidden, "insufficient_permissions", "Insufficient permissions")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), loggerKey{}, l)))
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+func TestLoginIssuesUsableToken(t *testing.T) {
+	_, cars := newTestRouter(t)
+	auth := service.NewAuthService(cars, cars, testAuthConfig)
+	if err := auth.CreateUser(context.Background(), "alice", "correct horse", service.RoleAdmin); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	router := NewHandler(Services{Rentals: service.NewRentalService(cars), Auth: auth}, Config{}).Router()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"wrong"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"correct horse"}`))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", rec.Code, rec.Body)
+	}
+	var token tokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&token); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	if token.TokenType != "Bearer" || token.ExpiresIn != 3600 {
+		t.Fatalf("token = %+v, want a Bearer token valid for 3600s", token)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(`{"model":"Honda Civic","registration":"DEF456","mileage":3200}`))
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("add car with token: status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMutatingRoutesRequireToken(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, target := range []string{"/cars", "/cars/X/rentals", "/cars/X/returns"} {
+		for _, header := range []string{"", "Bearer wrong", "Basic " + testToken} {
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{}`))
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("POST %s with %q: status %d, want %d", target, header, rec.Code, http.StatusUnauthorized)
+			}
+			if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("POST %s with %q: WWW-Authenticate = %q", target, header, rec.Header().Get("WWW-Authenticate"))
+			}
+		}
+	}
+
+	// Reads stay public.
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /cars without token: status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRolesPerRoute(t *testing.T) {
+	tests := []struct {
+		token, method, target string
+		want                  int
+	}{
+		{agentToken, http.MethodPost, "/cars", http.StatusForbidden},
+		{customerToken, http.MethodPost, "/cars", http.StatusForbidden},
+		{testToken, http.MethodPost, "/cars", http.StatusOK},
+		{customerToken, http.MethodPost, "/cars/DEF456/rentals", http.StatusForbidden},
+		{agentToken, http.MethodPost, "/cars/DEF456/rentals", http.StatusOK},
+		{customerToken, http.MethodPost, "/cars/DEF456/returns", http.StatusForbidden},
+		{agentToken, http.MethodPost, "/cars/DEF456/returns", http.StatusOK},
+		{customerToken, http.MethodGet, "/cleaning/tasks", http.StatusForbidden},
+		{agentToken, http.MethodGet, "/cleaning/tasks", http.StatusOK},
+		{customerToken, http.MethodGet, "/cars/search?q=civic", http.StatusForbidden},
+		{agentToken, http.MethodGet, "/cars/search?q=civic", http.StatusOK},
+		{customerToken, http.MethodGet, "/categories/availability", http.StatusOK},
+		{customerToken, http.MethodPost, "/categories", http.StatusForbidden},
+		{agentToken, http.MethodPost, "/cleaning/tasks/1/completion", http.StatusOK},
+		{agentToken, http.MethodGet, "/users", http.StatusForbidden},
+		{customerToken, http.MethodGet, "/changes", http.StatusForbidden},
+		{agentToken, http.MethodGet, "/changes", http.StatusOK},
+		{customerToken, http.MethodGet, "/cars", http.StatusOK},
+	}
+
+	router, _ := newTestRouter(t)
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"model":"Honda Civic","registration":"DEF456","mileage":3200}`))
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s as %s: status %d, want %d", tt.method, tt.target, tt.token, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// Long polls are held for defaultPollWait unless the client asks for less
+// with ?wait=, in seconds, up to maxPollWait.
+const (
+	defaultPollWait = 30 * time.Second
+	maxPollWait     = 60 * time.Second
+)
+
+// availabilityPoll is the response body of GET /cars/availability/poll.
+// Cursor is passed as since on the next poll; it is a change log cursor, so
+// it can also be given to GET /changes.
+type availabilityPoll struct {
+	Cars   []store.Car `json:"cars"`
+	Cursor string      `json:"cursor"`
+	// Changed is false when the poll timed out without a change.
+	Changed bool `json:"changed"`
+}
+
+// pollAvailability returns the available cars once a car changes after
+// since, or when the wait runs out. Without since it returns the current
+// availability and cursor at once.
+func (h *Handler) pollAvailability(w http.ResponseWriter, r *http.Request) {
+	wait := defaultPollWait
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds <= 0 || seconds > int(maxPollWait/time.Second) {
+			logger(r).Info("Invalid wait", "wait", waitStr)                                // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid wait") // Return appropriate HTTP status code
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	var (
+		cursor  int64
+		changed = true
+		err     error
+	)
+	if sinceStr := r.URL.Query().Get("since"); sinceStr == "" {
+		cursor, err = h.changes.Latest(r.Context(), store.EntityCar)
+	} else {
+		since, parseErr := strconv.ParseInt(sinceStr, 10, 64)
+		if parseErr != nil || since < 0 {
+			logger(r).Info("Invalid cursor", "since", sinceStr)                              // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid cursor") // Return appropriate HTTP status code
+			return
+		}
+		cursor, changed, err = h.waitForChange(w, r, since, wait)
+	}
+	if r.Context().Err() != nil {
+		// The client went away; there is no one to answer.
+		return
+	}
+	if err != nil {
+		logger(r).Error("Error querying changes", "error", err)                                                 // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve availability") // Return appropriate HTTP status code
+		return
+	}
+
+	// Reading the cars after the cursor means they are at least as new as
+	// the cursor, so a change racing the read is reported again next poll
+	// rather than lost.
+	// The response is the whole availability, not a page of it: a client
+	// fetching further pages would read them at a newer cursor.
+	cars, err := h.rentals.AvailableCars(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying data", "error", err)                                                      // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve available cars") // Return appropriate HTTP status code
+		return
+	}
+	if cars == nil {
+		cars = []store.Car{}
+	}
+
+	resp := availabilityPoll{Cars: cars, Cursor: strconv.FormatInt(cursor, 10), Changed: changed}
+	if err := encodeResponse(w, r, resp); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// waitForChange waits up to wait for a car change after since. It reports
+// changed false when the wait ran out or the server is draining.
+func (h *Handler) waitForChange(w http.ResponseWriter, r *http.Request, since int64, wait time.Duration) (int64, bool, error) {
+	// The server write timeout is usually shorter than a long poll.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger(r).Error("Error extending write deadline", "error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	go func() {
+		select {
+		case <-h.draining:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	cursor, err := h.changes.WaitFor(ctx, store.EntityCar, since)
+	if err != nil && ctx.Err() != nil && r.Context().Err() == nil {
+		return since, false, nil
+	}
+	return cursor, err == nil, err
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+func poll(t *testing.T, router http.Handler, target string) availabilityPoll {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodGet, target, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body)
+	}
+	var resp availabilityPoll
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode poll: %v", err)
+	}
+	return resp
+}
+
+func TestPollAvailability(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	resp := poll(t, router, "/cars/availability/poll")
+	if len(resp.Cars) != 0 || resp.Cursor != "0" || !resp.Changed {
+		t.Fatalf("initial poll = %+v, want no cars at cursor 0", resp)
+	}
+
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	resp = poll(t, router, "/cars/availability/poll?since=0")
+	if len(resp.Cars) != 1 || resp.Cursor == "0" || !resp.Changed {
+		t.Fatalf("poll behind the log = %+v, want DEF456 and a new cursor", resp)
+	}
+	cursor := resp.Cursor
+
+	start := time.Now()
+	resp = poll(t, router, "/cars/availability/poll?wait=1&since="+cursor)
+	if resp.Changed || resp.Cursor != cursor || len(resp.Cars) != 1 {
+		t.Fatalf("timed out poll = %+v, want unchanged at cursor %s", resp, cursor)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("timed out poll returned after %v, want it held for 1s", elapsed)
+	}
+
+	done := make(chan availabilityPoll)
+	go func() {
+		rec := doRequest(t, router, http.MethodGet, "/cars/availability/poll?wait=10&since="+cursor, "")
+		var resp availabilityPoll
+		json.NewDecoder(rec.Body).Decode(&resp)
+		done <- resp
+	}()
+	time.Sleep(100 * time.Millisecond)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after the car was rented")
+	}
+	if !resp.Changed || resp.Cursor == cursor || len(resp.Cars) != 0 {
+		t.Fatalf("poll after rental = %+v, want no cars and a new cursor", resp)
+	}
+}
+
+func TestPollAvailabilityIsUnpaged(t *testing.T) {
+	router, cars := newTestRouter(t)
+	n := service.MaxCarsPerPage + 1
+	for i := 0; i < n; i++ {
+		car := store.Car{Model: "Toyota Corolla", Registration: fmt.Sprintf("CAR%04d", i)}
+		if err := cars.Add(context.Background(), car); err != nil {
+			t.Fatalf("add car %d: %v", i, err)
+		}
+	}
+
+	if resp := poll(t, router, "/cars/availability/poll"); len(resp.Cars) != n {
+		t.Fatalf("poll returned %d cars, want all %d", len(resp.Cars), n)
+	}
+}
+
+func TestDrainEndsPolls(t *testing.T) {
+	_, cars := newTestRouter(t)
+	h := NewHandler(Services{Rentals: service.NewRentalService(cars), Changes: service.NewChangeService(cars)}, Config{})
+	router := h.Router()
+
+	done := make(chan availabilityPoll)
+	go func() {
+		rec := doRequest(t, router, http.MethodGet, "/cars/availability/poll?since=0", "")
+		var resp availabilityPoll
+		json.NewDecoder(rec.Body).Decode(&resp)
+		done <- resp
+	}()
+	time.Sleep(100 * time.Millisecond)
+	h.Drain()
+	select {
+	case resp := <-done:
+		if resp.Changed || resp.Cursor != "0" {
+			t.Fatalf("drained poll = %+v, want unchanged at cursor 0", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after Drain")
+	}
+}
+
+func TestPollAvailabilityInvalidParams(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, target := range []string{
+		"/cars/availability/poll?since=abc",
+		"/cars/availability/poll?since=-1",
+		"/cars/availability/poll?wait=0",
+		"/cars/availability/poll?wait=61",
+	} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
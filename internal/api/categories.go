@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+type updateCategoryRequest struct {
+	Description string `json:"description"`
+}
+
+func (h *Handler) listCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categories.Categories(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying categories", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve categories") // Return appropriate HTTP status code
+		return
+	}
+	if categories == nil {
+		categories = []store.Category{}
+	}
+
+	if err := encodeResponse(w, r, categories); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) addCategory(w http.ResponseWriter, r *http.Request) {
+	var c store.Category
+	if !decodeJSON(w, r, &c) {
+		return
+	}
+
+	err := h.categories.AddCategory(r.Context(), c)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid category", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The category is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCategoryExists):
+		logger(r).Info("Category already exists", "category", c.Name)                         // Log detailed error information
+		writeProblem(w, r, http.StatusConflict, "category_exists", "Category already exists") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error inserting data", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to add category") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Category added successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// updateCategory replaces the description of a category. Categories cannot
+// be renamed, as cars refer to them by name.
+func (h *Handler) updateCategory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req updateCategoryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	updated, err := h.categories.UpdateCategory(r.Context(), store.Category{Name: name, Description: req.Description})
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid category", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The category is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCategoryNotFound):
+		logger(r).Info("Category not found", "category", name)                              // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "category_not_found", "Category not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update category") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, updated); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	err := h.categories.DeleteCategory(r.Context(), name)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		logger(r).Info("Category not found", "category", name)                              // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "category_not_found", "Category not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCategoryInUse):
+		logger(r).Info("Category in use", "category", name)                                                    // Log detailed error information
+		writeProblem(w, r, http.StatusConflict, "category_in_use", "Category still has cars; move them first") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to delete category") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Category deleted successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// categoryAvailability counts the available cars of each category, for
+// customers who book a class of car rather than a registration.
+func (h *Handler) categoryAvailability(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.categories.Availability(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying data", "error", err)                                                    // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve availability") // Return appropriate HTTP status code
+		return
+	}
+	if counts == nil {
+		counts = []store.CategoryAvailability{}
+	}
+
+	if err := encodeResponse(w, r, counts); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
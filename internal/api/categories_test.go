@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"backendGo/internal/store"
+)
+
+func TestCategories(t *testing.T) {
+	router, _ := newTestRouter(t)
+	for _, car := range []string{
+		`{"model":"Corolla","registration":"ABC123","mileage":10,"category":"economy"}`,
+		`{"model":"Yaris","registration":"DEF456","mileage":10,"category":"economy"}`,
+		`{"model":"Transit","registration":"GHI789","mileage":10,"category":"van"}`,
+		`{"model":"Civic","registration":"JKL012","mileage":10}`,
+	} {
+		if rec := doRequest(t, router, http.MethodPost, "/cars", car); rec.Code != http.StatusOK {
+			t.Fatalf("POST /cars %s: status %d: %s", car, rec.Code, rec.Body)
+		}
+	}
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+
+	var counts []store.CategoryAvailability
+	rec := doRequest(t, router, http.MethodGet, "/categories/availability", "")
+	if err := json.NewDecoder(rec.Body).Decode(&counts); err != nil {
+		t.Fatalf("decode availability: %v", err)
+	}
+	got := make(map[string][2]int)
+	for _, c := range counts {
+		got[c.Name] = [2]int{c.Available, c.Total}
+	}
+	want := map[string][2]int{"economy": {1, 2}, "luxury": {0, 0}, "suv": {0, 0}, "van": {1, 1}}
+	if len(got) != len(want) {
+		t.Fatalf("availability = %+v, want the four seed categories", counts)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s: available, total = %v, want %v", name, got[name], w)
+		}
+	}
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/cars", `{"model":"Golf","registration":"MNO345","category":"compact"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/categories", `{"name":"compact","description":"Small cars"}`, http.StatusOK},
+		{http.MethodPost, "/categories", `{"name":"compact","description":"Again"}`, http.StatusConflict},
+		{http.MethodPost, "/categories", `{"name":"Compact Plus","description":""}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/cars", `{"model":"Golf","registration":"MNO345","category":"compact"}`, http.StatusOK},
+		{http.MethodPut, "/categories/compact", `{"description":"Small city cars"}`, http.StatusOK},
+		{http.MethodPut, "/categories/nope", `{"description":"Nothing"}`, http.StatusNotFound},
+		{http.MethodDelete, "/categories/compact", "", http.StatusConflict},
+		{http.MethodDelete, "/cars/MNO345", "", http.StatusOK},
+		{http.MethodDelete, "/categories/compact", "", http.StatusConflict},
+		{http.MethodPatch, "/cars/MNO345", `{"category":null}`, http.StatusNotFound},
+		{http.MethodDelete, "/categories/suv", "", http.StatusOK},
+		{http.MethodDelete, "/categories/suv", "", http.StatusNotFound},
+		{http.MethodPatch, "/cars/JKL012", `{"category":"suv"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(t, router, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s: status %d, want %d: %s", tt.method, tt.target, tt.body, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	var categories []store.Category
+	rec = doRequest(t, router, http.MethodGet, "/categories", "")
+	if err := json.NewDecoder(rec.Body).Decode(&categories); err != nil || len(categories) != 4 || categories[0] != (store.Category{Name: "compact", Description: "Small city cars"}) {
+		t.Errorf("categories = %+v (%v), want compact first of four", categories, err)
+	}
+}
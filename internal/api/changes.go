@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"backendGo/internal/store"
+)
+
+const defaultChangesLimit = 100
+
+// changesPage is the response body of GET /changes. NextCursor is passed as
+// since on the next request to resume after the last returned change.
+type changesPage struct {
+	Changes    []store.Change `json:"changes"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+func (h *Handler) listChanges(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		var err error
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			logger(r).Info("Invalid cursor", "since", sinceStr)                              // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid cursor") // Return appropriate HTTP status code
+			return
+		}
+	}
+
+	limit := defaultChangesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			logger(r).Info("Invalid limit", "limit", limitStr)                              // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid limit") // Return appropriate HTTP status code
+			return
+		}
+	}
+
+	changes, err := h.changes.Since(r.Context(), since, limit)
+	if err != nil {
+		logger(r).Error("Error querying changes", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve changes") // Return appropriate HTTP status code
+		return
+	}
+
+	page := changesPage{Changes: changes, NextCursor: strconv.FormatInt(since, 10)}
+	if page.Changes == nil {
+		page.Changes = []store.Change{}
+	}
+	if n := len(changes); n > 0 {
+		page.NextCursor = strconv.FormatInt(changes[n-1].ID, 10)
+	}
+
+	if err := encodeResponse(w, r, page); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func getChanges(t *testing.T, router http.Handler, target string) changesPage {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodGet, target, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body)
+	}
+	var page changesPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode changes: %v", err)
+	}
+	return page
+}
+
+func TestChangesFeed(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	page := getChanges(t, router, "/changes")
+	if len(page.Changes) != 0 || page.NextCursor != "0" {
+		t.Fatalf("empty feed = %+v, want no changes and cursor 0", page)
+	}
+
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "") // rejected, not recorded
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/returns", "")
+
+	page = getChanges(t, router, "/changes?limit=2")
+	if len(page.Changes) != 2 || page.Changes[0].Operation != "create" || page.Changes[1].Operation != "update" {
+		t.Fatalf("first page = %+v, want create then update", page.Changes)
+	}
+	if page.Changes[0].EntityType != "car" || page.Changes[0].EntityID != "DEF456" {
+		t.Fatalf("first change = %+v, want car DEF456", page.Changes[0])
+	}
+
+	// The return opens a cleaning task.
+	page = getChanges(t, router, "/changes?since="+page.NextCursor)
+	if len(page.Changes) != 2 || page.Changes[0].EntityType != "cleaning_task" || page.Changes[0].Operation != "create" ||
+		page.Changes[1].EntityType != "car" || page.Changes[1].Operation != "update" {
+		t.Fatalf("second page = %+v, want a cleaning task created and a car update", page.Changes)
+	}
+
+	cursor := page.NextCursor
+	page = getChanges(t, router, "/changes?since="+cursor)
+	if len(page.Changes) != 0 || page.NextCursor != cursor {
+		t.Fatalf("caught-up page = %+v, want no changes and cursor %s", page, cursor)
+	}
+}
+
+func TestChangesInvalidParams(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, target := range []string{"/changes?since=abc", "/changes?since=-1", "/changes?limit=0"} {
+		if rec := doRequest(t, router, http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
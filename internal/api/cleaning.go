@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backendGo/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type assignCleaningRequest struct {
+	Assignee string `json:"assignee"`
+}
+
+func (h *Handler) listCleaningTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.cleaning.Tasks(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying cleaning tasks", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve cleaning tasks") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, tasks); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) assignCleaningTask(w http.ResponseWriter, r *http.Request) {
+	var req assignCleaningRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// The route only matches digits, so parsing fails only on overflow,
+	// and no such task exists either.
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	task, err := h.cleaning.Assign(r.Context(), id, req.Assignee)
+	writeCleaningTask(w, r, id, task, err)
+}
+
+// completeCleaningTask records that a car was cleaned by the caller and can
+// be rented again.
+func (h *Handler) completeCleaningTask(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	p, _ := principal(r)
+	task, err := h.cleaning.Complete(r.Context(), id, p.Username)
+	writeCleaningTask(w, r, id, task, err)
+}
+
+// writeCleaningTask answers a change to cleaning task id with the updated
+// task or the error that prevented the change.
+func writeCleaningTask(w http.ResponseWriter, r *http.Request, id int64, task service.CleaningTask, err error) {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid cleaning task change", "error", err)          // Log detailed error information
+		writeValidationProblem(w, r, "The change is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCleaningTaskNotFound):
+		logger(r).Info("Cleaning task not found", "task", id)                                         // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "cleaning_task_not_found", "Cleaning task not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCleaningTaskDone):
+		logger(r).Info("Cleaning task already completed", "task", id)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusConflict, "cleaning_task_completed", "Cleaning task is already completed") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                               // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update cleaning task") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, task); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
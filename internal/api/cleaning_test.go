@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+func TestCleaningWorkflow(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/returns", "")
+
+	rec := doRequest(t, router, http.MethodGet, "/cars/DEF456", "")
+	if !containsJSON(t, rec.Body.Bytes(), "needs_cleaning", true) {
+		t.Fatalf("car after return = %s, want needs_cleaning", rec.Body)
+	}
+
+	var tasks []service.CleaningTask
+	rec = doRequest(t, router, http.MethodGet, "/cleaning/tasks", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &tasks); err != nil || len(tasks) != 1 || tasks[0].Registration != "DEF456" || tasks[0].Assignee != "" {
+		t.Fatalf("open tasks = %s (%v), want one unassigned task for DEF456", rec.Body, err)
+	}
+	if due := tasks[0].DueAt.Sub(tasks[0].ReturnedAt); due.Hours() != 1 || tasks[0].Overdue {
+		t.Errorf("task due %v after return, overdue %v; want 1h, not overdue", due, tasks[0].Overdue)
+	}
+
+	var task service.CleaningTask
+	rec = doRequest(t, router, http.MethodPut, "/cleaning/tasks/1/assignee", `{"assignee":"bob"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &task); err != nil || task.Assignee != "bob" {
+		t.Fatalf("assigned task = %s (%v), want assignee bob", rec.Body, err)
+	}
+	rec = doRequest(t, router, http.MethodPost, "/cleaning/tasks/1/completion", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &task); err != nil || task.CleanedAt == nil || task.CleanedBy != "tester" {
+		t.Fatalf("completed task = %s (%v), want cleaned by tester", rec.Body, err)
+	}
+	if cars := availableCars(t, router); len(cars) != 1 || cars[0].NeedsCleaning {
+		t.Fatalf("available after cleaning = %+v, want DEF456", cars)
+	}
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+		code                 string
+	}{
+		{http.MethodPost, "/cleaning/tasks/1/completion", "", http.StatusConflict, "cleaning_task_completed"},
+		{http.MethodPut, "/cleaning/tasks/1/assignee", `{"assignee":"eve"}`, http.StatusConflict, "cleaning_task_completed"},
+		{http.MethodPost, "/cleaning/tasks/9/completion", "", http.StatusNotFound, "cleaning_task_not_found"},
+		{http.MethodPut, "/cleaning/tasks/9/assignee", `{"assignee":"eve"}`, http.StatusNotFound, "cleaning_task_not_found"},
+		{http.MethodPut, "/cleaning/tasks/9/assignee", `{"assignee":" "}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{http.MethodPost, "/cleaning/tasks/99999999999999999999/completion", "", http.StatusNotFound, "cleaning_task_not_found"},
+		{http.MethodPatch, "/cars/DEF456", `{"needs_cleaning":true}`, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, router, tt.method, tt.target, tt.body)
+		if rec.Code != tt.want || !containsJSON(t, rec.Body.Bytes(), "code", tt.code) {
+			t.Errorf("%s %s: status %d: %s, want %d %s", tt.method, tt.target, rec.Code, rec.Body, tt.want, tt.code)
+		}
+	}
+}
+
+// containsJSON reports whether the JSON object body has member key with
+// value want.
+func containsJSON(t *testing.T, body []byte, key string, want interface{}) bool {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return false
+	}
+	return obj[key] == want
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS lets browser applications on other origins call the API. It is
+// disabled while AllowedOrigins is empty.
+type CORS struct {
+	// AllowedOrigins lists origins such as https://dashboard.example.com.
+	// "*" allows any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are the request headers a browser may send, such as
+	// Authorization.
+	AllowedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// corsExposedHeaders are the response headers cross-origin scripts may
+// read, besides those browsers always expose.
+var corsExposedHeaders = strings.Join([]string{
+	RequestIDHeader,
+	"WWW-Authenticate",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"Retry-After",
+	"Deprecation",
+	"Sunset",
+	"Link",
+	totalCountHeader,
+	nextCursorHeader,
+	"ETag",
+}, ", ")
+
+func (c CORS) allows(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// cors adds CORS headers for allowed origins and answers preflight
+// requests. It wraps the router rather than being router middleware,
+// because preflight OPTIONS requests match no route.
+func (h *Handler) cors(next http.Handler) http.Handler {
+	c := h.config.CORS
+	if len(c.AllowedOrigins) == 0 {
+		return next
+	}
+	allowOrigin := func(origin string) string {
+		if slices.Contains(c.AllowedOrigins, "*") {
+			return "*"
+		}
+		return origin
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !c.allows(origin) {
+			if preflight {
+				logger(r).Info("CORS origin not allowed", "origin", origin)
+				writeProblem(w, r, http.StatusForbidden, "origin_not_allowed", "Origin not allowed")
+				return
+			}
+			// Without CORS headers the browser hides the response from
+			// the calling script.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin(origin))
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+		if len(c.AllowedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+		}
+		if c.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
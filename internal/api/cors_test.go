@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+)
+
+func newCORSRouter(t *testing.T, origins ...string) http.Handler {
+	t.Helper()
+
+	_, cars := newTestRouter(t)
+	return NewHandler(Services{Rentals: service.NewRentalService(cars), Auth: fakeAuth{}}, Config{CORS: CORS{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}}).Router()
+}
+
+func corsRequest(router http.Handler, method, target, origin string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Origin", origin)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter(t, "https://dashboard.example.com")
+
+	rec := corsRequest(router, http.MethodOptions, "/cars", "https://dashboard.example.com", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "authorization, content-type",
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://dashboard.example.com",
+		"Access-Control-Allow-Methods": "GET, POST",
+		"Access-Control-Allow-Headers": "Authorization, Content-Type",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	rec = corsRequest(router, http.MethodOptions, "/cars", "https://evil.example.com", map[string]string{"Access-Control-Request-Method": "POST"})
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("preflight from other origin: status %d, allow origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	router := newCORSRouter(t, "https://dashboard.example.com")
+
+	rec := corsRequest(router, http.MethodGet, "/cars", "https://dashboard.example.com", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" {
+		t.Fatalf("allowed origin: status %d, allow origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), RequestIDHeader) {
+		t.Errorf("Access-Control-Expose-Headers = %q, want it to include %s", rec.Header().Get("Access-Control-Expose-Headers"), RequestIDHeader)
+	}
+	if vary := rec.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Origin") {
+		t.Errorf("Vary = %q, want Origin", vary)
+	}
+
+	rec = corsRequest(router, http.MethodGet, "/cars", "https://evil.example.com", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("other origin: status %d, allow origin %q, want no CORS headers", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSWildcardAndDisabled(t *testing.T) {
+	rec := corsRequest(newCORSRouter(t, "*"), http.MethodGet, "/cars", "http://localhost:3000", nil)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("wildcard: allow origin %q, want *", got)
+	}
+
+	router := newCORSRouter(t)
+	rec = corsRequest(router, http.MethodOptions, "/cars", "http://localhost:3000", map[string]string{"Access-Control-Request-Method": "GET"})
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disabled: status %d, allow origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// deprecation describes an endpoint or field scheduled for removal.
+// Surfaces are named "METHOD /route/template" for endpoints, with
+// "#field" appended for fields.
+type deprecation struct {
+	// since is announced in the Deprecation header (RFC 9745).
+	since time.Time
+	// sunset, if set, is when the surface stops working, announced in the
+	// Sunset header (RFC 8594).
+	sunset time.Time
+	// link, if set, documents the replacement.
+	link string
+}
+
+// deprecate registers surface as deprecated. Call it while building the
+// router, then mark requests with deprecated or useDeprecated.
+func (h *Handler) deprecate(surface string, d deprecation) {
+	h.deprecations[surface] = d
+}
+
+// deprecated marks every request to next as using surface.
+func (h *Handler) deprecated(surface string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.useDeprecated(w, r, surface)
+		next(w, r)
+	}
+}
+
+// useDeprecated announces the deprecation of surface in the response
+// headers and records which client used it. It must be called before the
+// response is written.
+func (h *Handler) useDeprecated(w http.ResponseWriter, r *http.Request, surface string) {
+	d, ok := h.deprecations[surface]
+	if !ok {
+		logger(r).Error("Unregistered deprecated surface", "surface", surface)
+		return
+	}
+
+	w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.since.Unix(), 10))
+	if !d.sunset.IsZero() {
+		w.Header().Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.link != "" {
+		w.Header().Add("Link", "<"+d.link+`>; rel="deprecation"`)
+	}
+
+	// Usage tracking is best effort and never fails the request.
+	if err := h.deprecationUsage.Record(r.Context(), surface, clientName(r)); err != nil {
+		logger(r).Error("Error recording deprecated usage", "surface", surface, "error", err)
+	}
+}
+
+// clientName identifies the caller in usage reports.
+func clientName(r *http.Request) string {
+	if p, ok := principal(r); ok {
+		return p.Username
+	}
+	return "anonymous"
+}
+
+type deprecatedClient struct {
+	Client    string    `json:"client"`
+	Requests  int64     `json:"requests"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type deprecatedSurface struct {
+	Surface         string             `json:"surface"`
+	DeprecatedSince time.Time          `json:"deprecated_since"`
+	Sunset          *time.Time         `json:"sunset,omitempty"`
+	Link            string             `json:"link,omitempty"`
+	Clients         []deprecatedClient `json:"clients"`
+}
+
+// deprecationReport lists every deprecated surface and the clients that
+// still use it. Usage of surfaces that are no longer registered, because
+// they have been removed, is left out.
+func (h *Handler) deprecationReport(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.deprecationUsage.Usage(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying deprecated usage", "error", err)                                              // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve deprecation report") // Return appropriate HTTP status code
+		return
+	}
+	bySurface := make(map[string][]store.DeprecatedUsage)
+	for _, u := range usage {
+		bySurface[u.Surface] = append(bySurface[u.Surface], u)
+	}
+
+	report := make([]deprecatedSurface, 0, len(h.deprecations))
+	for surface, d := range h.deprecations {
+		entry := deprecatedSurface{
+			Surface:         surface,
+			DeprecatedSince: d.since,
+			Link:            d.link,
+			Clients:         []deprecatedClient{},
+		}
+		if !d.sunset.IsZero() {
+			sunset := d.sunset
+			entry.Sunset = &sunset
+		}
+		for _, u := range bySurface[surface] {
+			entry.Clients = append(entry.Clients, deprecatedClient{
+				Client:    u.Client,
+				Requests:  u.Requests,
+				FirstSeen: u.FirstSeen,
+				LastSeen:  u.LastSeen,
+			})
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Surface < report[j].Surface })
+
+	if err := encodeResponse(w, r, report); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+)
+
+func TestDeprecatedSurface(t *testing.T) {
+	_, cars := newTestRouter(t)
+	h := NewHandler(Services{
+		Auth:         fakeAuth{},
+		Deprecations: service.NewDeprecationService(cars),
+	}, Config{})
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	h.deprecate("GET /old", deprecation{since: since, sunset: sunset, link: "https://example.com/migrate"})
+	old := h.authenticate(h.requireRole(h.deprecated("GET /old", func(w http.ResponseWriter, r *http.Request) {}), service.RoleAgent))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/old", nil)
+		req.Header.Set("Authorization", "Bearer "+agentToken)
+		rec := httptest.NewRecorder()
+		old.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Deprecation"); got != "@1704067200" {
+			t.Errorf("Deprecation = %q, want @1704067200", got)
+		}
+		if got := rec.Header().Get("Sunset"); got != "Mon, 01 Jul 2024 00:00:00 GMT" {
+			t.Errorf("Sunset = %q", got)
+		}
+		if got := rec.Header().Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+			t.Errorf("Link = %q", got)
+		}
+	}
+
+	rec := doRequest(t, h.Router(), http.MethodGet, "/deprecations", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /deprecations: status %d: %s", rec.Code, rec.Body)
+	}
+	var report []deprecatedSurface
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if len(report) != 1 || report[0].Surface != "GET /old" || report[0].Sunset == nil || !report[0].Sunset.Equal(sunset) {
+		t.Fatalf("report = %+v, want GET /old with its sunset", report)
+	}
+	if clients := report[0].Clients; len(clients) != 1 || clients[0].Client != "agent" || clients[0].Requests != 2 {
+		t.Fatalf("clients = %+v, want agent with 2 requests", clients)
+	}
+}
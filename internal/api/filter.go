@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backendGo/internal/store"
+)
+
+// readFilter sets the filters and order of q from the rented, model,
+// min_mileage, max_mileage and sort query parameters. It writes a problem
+// and returns false if one is malformed or they contradict each other.
+func readFilter(w http.ResponseWriter, r *http.Request, q *store.CarQuery) bool {
+	params := r.URL.Query()
+	invalid := func(name string) bool {
+		logger(r).Info("Invalid "+name, name, params.Get(name))                         // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid "+name) // Return appropriate HTTP status code
+		return false
+	}
+
+	if v := params.Get("rented"); v != "" {
+		rented, err := strconv.ParseBool(v)
+		if err != nil {
+			return invalid("rented")
+		}
+		q.Rented = &rented
+	}
+	q.Model = strings.TrimSpace(params.Get("model"))
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"min_mileage", &q.MinMileage}, {"max_mileage", &q.MaxMileage}} {
+		v := params.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return invalid(p.name)
+		}
+		*p.dst = &n
+	}
+	if q.MinMileage != nil && q.MaxMileage != nil && *q.MinMileage > *q.MaxMileage {
+		logger(r).Info("Empty mileage range", "min_mileage", *q.MinMileage, "max_mileage", *q.MaxMileage)         // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "min_mileage must not exceed max_mileage") // Return appropriate HTTP status code
+		return false
+	}
+
+	if v := params.Get("sort"); v != "" {
+		sort, ok := parseSort(v)
+		if !ok {
+			return invalid("sort")
+		}
+		q.Sort = sort
+	}
+	// A cursor is a registration, so it only marks a place in the default
+	// order.
+	if q.After != "" && !cursorOrder(q.Sort) {
+		logger(r).Info("Cursor with sort", "sort", params.Get("sort"))                                         // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Cursors cannot be combined with sort") // Return appropriate HTTP status code
+		return false
+	}
+	return true
+}
+
+// parseSort parses a sort parameter of the form field or field:asc or
+// field:desc.
+func parseSort(v string) (store.CarSort, bool) {
+	field, dir, _ := strings.Cut(v, ":")
+	sort := store.CarSort{Field: field}
+	switch field {
+	case store.SortRegistration, store.SortModel, store.SortMileage:
+	default:
+		return store.CarSort{}, false
+	}
+	switch dir {
+	case "", "asc":
+	case "desc":
+		sort.Desc = true
+	default:
+		return store.CarSort{}, false
+	}
+	return sort, true
+}
+
+// cursorOrder reports whether sort is the registration order that cursors
+// mark places in.
+func cursorOrder(sort store.CarSort) bool {
+	return sort == store.CarSort{} || sort == store.CarSort{Field: store.SortRegistration}
+}
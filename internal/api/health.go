@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type healthStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthz reports that the process is up. It checks no dependencies, so a
+// failing database does not get the process restarted.
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, r, http.StatusOK, healthStatus{Status: "ok"})
+}
+
+// readyz reports whether the service can take traffic: the database must
+// answer and every migration must be applied.
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	if err := h.health.Ready(r.Context()); err != nil {
+		logger(r).Warn("Not ready", "error", err)
+		writeHealth(w, r, http.StatusServiceUnavailable, healthStatus{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	writeHealth(w, r, http.StatusOK, healthStatus{Status: "ready"})
+}
+
+func writeHealth(w http.ResponseWriter, r *http.Request, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+type fakeHealth struct{ err error }
+
+func (f fakeHealth) Ready(context.Context) error { return f.err }
+
+func TestHealthz(t *testing.T) {
+	router := NewHandler(Services{Health: fakeHealth{err: service.ErrDatabaseUnavailable}}, Config{}).Router()
+
+	if rec := doRequest(t, router, http.MethodGet, "/healthz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d even when not ready", rec.Code, http.StatusOK)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	router, _ := newTestRouter(t)
+	if rec := doRequest(t, router, http.MethodGet, "/readyz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("migrated database: status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	for _, err := range []error{
+		fmt.Errorf("%w: connection refused", service.ErrDatabaseUnavailable),
+		fmt.Errorf("%w: 1 not applied", service.ErrMigrationsPending),
+	} {
+		router := NewHandler(Services{Health: fakeHealth{err: err}}, Config{}).Router()
+		if rec := doRequest(t, router, http.MethodGet, "/readyz", ""); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%v: status %d, want %d", err, rec.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+type addKeyRequest struct {
+	Kind string `json:"kind"`
+	store.KeyLocation
+}
+
+func (h *Handler) listCarKeys(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+
+	keys, err := h.keys.Keys(r.Context(), registration)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error querying keys", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve keys") // Return appropriate HTTP status code
+		return
+	}
+	if keys == nil {
+		keys = []store.CarKey{}
+	}
+
+	if err := encodeResponse(w, r, keys); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) addCarKey(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+	var req addKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	p, _ := principal(r)
+	key, err := h.keys.AddKey(r.Context(), registration, req.Kind, req.KeyLocation, p.Username)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid key", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The key is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error inserting data", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to add key") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, key); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// handOverKey records that a key went to a holder or lockbox slot, or was
+// found missing.
+func (h *Handler) handOverKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var to store.KeyLocation
+	if !decodeJSON(w, r, &to) {
+		return
+	}
+
+	p, _ := principal(r)
+	key, err := h.keys.HandOver(r.Context(), id, to, p.Username)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid handover", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The handover is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrKeyNotFound):
+		logger(r).Info("Key not found", "key", id)                                // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "key_not_found", "Key not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to record handover") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, key); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) listKeyHandovers(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	handovers, err := h.keys.Handovers(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrKeyNotFound):
+		logger(r).Info("Key not found", "key", id)                                // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "key_not_found", "Key not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error querying handovers", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve handovers") // Return appropriate HTTP status code
+		return
+	}
+	if handovers == nil {
+		handovers = []store.KeyHandover{}
+	}
+
+	if err := encodeResponse(w, r, handovers); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) listKeyAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.keys.Alerts(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying key alerts", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve key alerts") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, alerts); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+func TestKeyInventory(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+
+	// call serves target and checks the body against schema before
+	// decoding it into v.
+	call := func(method, target, body, schema string, v interface{}) {
+		t.Helper()
+		rec := doRequest(t, router, method, target, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d: %s", method, target, rec.Code, rec.Body)
+		}
+		var raw interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, target, err)
+		}
+		if err := compileSchema(t, schema).Validate(raw); err != nil {
+			t.Fatalf("%s %s does not match %s: %v", method, target, schema, err)
+		}
+		json.Unmarshal(rec.Body.Bytes(), v)
+	}
+
+	var primary, spare store.CarKey
+	call(http.MethodPost, "/cars/DEF456/keys", `{"kind":"primary","slot":"A1"}`, "key.json", &primary)
+	call(http.MethodPost, "/cars/DEF456/keys", `{"kind":"spare","slot":"A2"}`, "key.json", &spare)
+	var keys []store.CarKey
+	call(http.MethodGet, "/cars/DEF456/keys", "", "keys.json", &keys)
+	if len(keys) != 2 {
+		t.Fatalf("keys = %+v, want primary and spare", keys)
+	}
+
+	// The customer takes the primary key and returns the car without
+	// handing it back; the spare goes missing.
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	var held store.CarKey
+	call(http.MethodPost, "/keys/"+primary.ID+"/handovers", `{"holder":"customer"}`, "key.json", &held)
+	if held.Holder != "customer" || held.Slot != "" {
+		t.Fatalf("handed over key = %+v, want held by customer", held)
+	}
+	call(http.MethodPost, "/keys/"+spare.ID+"/handovers", `{"missing":true}`, "key.json", &store.CarKey{})
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/returns", "")
+
+	var alerts []service.KeyAlert
+	call(http.MethodGet, "/keys/alerts", "", "key-alerts.json", &alerts)
+	if len(alerts) != 2 || alerts[0].Reason != service.AlertNotCheckedIn || alerts[1].Reason != service.AlertKeyMissing {
+		t.Fatalf("alerts = %+v, want primary not checked in and spare missing", alerts)
+	}
+
+	var handovers []store.KeyHandover
+	call(http.MethodGet, "/keys/"+primary.ID+"/handovers", "", "key-handovers.json", &handovers)
+	if len(handovers) != 2 || handovers[0].Slot != "A1" || handovers[1].Holder != "customer" || handovers[1].RecordedBy != "tester" {
+		t.Fatalf("handovers = %+v, want slot A1 then customer, recorded by tester", handovers)
+	}
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/cars/NOPE/keys", `{"kind":"spare","slot":"A3"}`, http.StatusNotFound},
+		{http.MethodGet, "/cars/NOPE/keys", "", http.StatusNotFound},
+		{http.MethodPost, "/cars/DEF456/keys", `{"kind":"spare"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/keys/" + primary.ID + "/handovers", `{"holder":"agent","slot":"A1"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/keys/nope/handovers", `{"slot":"A1"}`, http.StatusNotFound},
+		{http.MethodGet, "/keys/nope/handovers", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(t, router, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d: %s", tt.method, tt.target, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
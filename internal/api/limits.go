@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// limitBody caps the size of request bodies at Config.MaxBodyBytes. Reading
+// past the cap fails, so a handler never holds more than that in memory.
+func (h *Handler) limitBody(next http.Handler) http.Handler {
+	if h.config.MaxBodyBytes <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitDuration answers 503 Service Unavailable to requests still running
+// after Config.HandlerTimeout and cancels their context, which aborts
+// pending database queries. Long polls bound their own duration and are
+// exempt.
+func (h *Handler) limitDuration(next http.Handler) http.Handler {
+	if h.config.HandlerTimeout <= 0 {
+		return next
+	}
+	body, _ := json.Marshal(newProblem(http.StatusServiceUnavailable, "timeout", "Request timed out"))
+	timed := http.TimeoutHandler(next, h.config.HandlerTimeout, string(body))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routeTemplate(r) == "/cars/availability/poll" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		timed.ServeHTTP(timeoutProblemWriter{w}, r)
+	})
+}
+
+// timeoutProblemWriter labels the body http.TimeoutHandler writes on
+// timeout, which comes without a Content-Type, as a problem. Responses the
+// handler finished in time carry their own headers.
+type timeoutProblemWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutProblemWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", problemContentType)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w timeoutProblemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decodeJSON decodes the request body into v. On failure it answers 413
+// Request Entity Too Large if the body exceeded the size limit, or 400 Bad
+// Request otherwise, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logger(r).Info("Request body too large", "limit", tooLarge.Limit)                                // Log detailed error information
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large") // Return appropriate HTTP status code
+		return false
+	}
+	logger(r).Info("Error decoding JSON request", "error", err)                       // Log detailed error information
+	writeProblem(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body") // Return appropriate HTTP status code
+	return false
+}
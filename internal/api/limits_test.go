@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+func TestRequestBodyLimit(t *testing.T) {
+	_, cars := newTestRouter(t)
+	router := NewHandler(Services{Rentals: service.NewRentalService(cars), Auth: fakeAuth{}}, Config{MaxBodyBytes: 128}).Router()
+
+	huge := `{"model":"` + strings.Repeat("x", 1024) + `","registration":"DEF456","mileage":3200}`
+	if rec := doRequest(t, router, http.MethodPost, "/cars", huge); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("huge body: status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`); rec.Code != http.StatusOK {
+		t.Fatalf("small body: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/cars", `{"model":`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// slowRentals blocks listing cars until the request is cancelled.
+type slowRentals struct {
+	service.RentalService
+}
+
+func (slowRentals) ListAvailable(ctx context.Context, _ store.CarQuery) (service.CarPage, error) {
+	<-ctx.Done()
+	return service.CarPage{}, ctx.Err()
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	_, cars := newTestRouter(t)
+	router := NewHandler(Services{
+		Rentals: slowRentals{service.NewRentalService(cars)},
+		Changes: service.NewChangeService(cars),
+		Auth:    fakeAuth{},
+	}, Config{HandlerTimeout: 50 * time.Millisecond}).Router()
+
+	start := time.Now()
+	rec := doRequest(t, router, http.MethodGet, "/cars", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow handler: status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+		t.Errorf("slow handler: content type %q, want %q", ct, problemContentType)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("slow handler answered after %v", elapsed)
+	}
+
+	// Long polls bound their own wait and are exempt from the timeout.
+	router = NewHandler(Services{
+		Rentals: service.NewRentalService(cars),
+		Changes: service.NewChangeService(cars),
+		Auth:    fakeAuth{},
+	}, Config{HandlerTimeout: 50 * time.Millisecond}).Router()
+	cursor := poll(t, router, "/cars/availability/poll").Cursor
+	if got := poll(t, router, "/cars/availability/poll?wait=1&since="+cursor); got.Changed {
+		t.Fatalf("long poll = %+v, want unchanged after waiting", got)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// captureLogs sends the default logger's JSON output to the returned buffer
+// for the duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodGet, "/cars", "")
+	generated := rec.Header().Get(RequestIDHeader)
+	if len(generated) != 32 {
+		t.Fatalf("generated request id = %q, want 32 hex characters", generated)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.Header.Set(RequestIDHeader, "support-1234")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "support-1234" {
+		t.Fatalf("request id = %q, want the incoming support-1234", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); len(got) != 32 {
+		t.Fatalf("request id = %q, want a generated id replacing the invalid one", got)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	router, _ := newTestRouter(t)
+	logs := captureLogs(t)
+
+	rec := doRequest(t, router, http.MethodPost, "/cars/NOPE/rentals", "")
+	id := rec.Header().Get(RequestIDHeader)
+
+	var access map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		if record["request_id"] != id {
+			t.Errorf("log record %v is missing request id %s", record, id)
+		}
+		if record["msg"] == "Request served" {
+			access = record
+		}
+	}
+	if access == nil {
+		t.Fatal("no access log record")
+	}
+	if access["method"] != "POST" || access["path"] != "/cars/NOPE/rentals" || access["status"] != float64(http.StatusNotFound) {
+		t.Errorf("access log = %v, want POST /cars/NOPE/rentals with status 404", access)
+	}
+	if _, ok := access["duration_ms"]; !ok {
+		t.Errorf("access log = %v, want a duration_ms field", access)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backendGo/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestInstrumentUsesRouteTemplate(t *testing.T) {
+	router, _ := newTestRouter(t)
+	counter := metrics.HTTPRequests.WithLabelValues("/cars/{registration}/rentals", http.MethodPost, "404")
+	before := testutil.ToFloat64(counter)
+
+	doRequest(t, router, http.MethodPost, "/cars/NOPE/rentals", "")
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Fatalf("request counter increased by %v, want 1", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+
+	rec := doRequest(t, router, http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, name := range []string{
+		"carrental_http_requests_total",
+		"carrental_http_request_duration_seconds",
+		"carrental_db_query_duration_seconds",
+		"carrental_cars_rented_total",
+	} {
+		if !strings.Contains(body, name) {
+			t.Errorf("metrics output is missing %s", name)
+		}
+	}
+}
+
+func TestTraceRequestsContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	router, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var server sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /cars" {
+			server = span
+		}
+	}
+	if server == nil {
+		t.Fatal("no server span recorded for GET /cars")
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s, want the incoming one", got)
+	}
+	if len(recorder.Ended()) < 2 {
+		t.Errorf("recorded %d spans, want the server span and a storage span", len(recorder.Ended()))
+	}
+}
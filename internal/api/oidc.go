@@ -0,0 +1,114 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"backendGo/internal/service"
+
+	"golang.org/x/oauth2"
+)
+
+// oidcCookie carries the state, nonce and PKCE verifier of a login in
+// progress from /auth/oidc/login to the callback.
+const (
+	oidcCookie    = "carrental_oidc"
+	oidcCookieAge = 10 * time.Minute
+)
+
+func randomString() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// oidcLogin sends the user to the OIDC provider.
+func (h *Handler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := randomString()
+	if err != nil {
+		logger(r).Error("Error generating OIDC state", "error", err)                                  // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to start login") // Return appropriate HTTP status code
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		logger(r).Error("Error generating OIDC nonce", "error", err)                                  // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to start login") // Return appropriate HTTP status code
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    strings.Join([]string{state, nonce, verifier}, "."),
+		Path:     "/auth/oidc",
+		MaxAge:   int(oidcCookieAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.oidc.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// oidcCallback completes the login and issues an access token for the
+// role mapped from the user's provider groups.
+func (h *Handler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		logger(r).Info("OIDC provider refused login", "error", providerErr, "description", query.Get("error_description")) // Log detailed error information
+		writeProblem(w, r, http.StatusUnauthorized, "login_refused", "Login was refused by the identity provider")         // Return appropriate HTTP status code
+		return
+	}
+
+	var state, nonce, verifier string
+	if c, err := r.Cookie(oidcCookie); err == nil {
+		if parts := strings.Split(c.Value, "."); len(parts) == 3 {
+			state, nonce, verifier = parts[0], parts[1], parts[2]
+		}
+	}
+	// Clear the cookie whatever the outcome; each login attempt gets a
+	// fresh state.
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Path: "/auth/oidc", MaxAge: -1})
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		logger(r).Info("OIDC state mismatch")                                                   // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_login_state", "Invalid login state") // Return appropriate HTTP status code
+		return
+	}
+
+	p, err := h.oidc.Exchange(r.Context(), query.Get("code"), nonce, verifier)
+	switch {
+	case errors.Is(err, service.ErrNoRole):
+		logger(r).Info("OIDC user has no role", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusForbidden, "no_role", "No role is granted to this account") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrOIDCLogin):
+		logger(r).Info("OIDC login failed", "error", err)                           // Log detailed error information
+		writeProblem(w, r, http.StatusUnauthorized, "login_failed", "Login failed") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error completing OIDC login", "error", err)                             // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to log in") // Return appropriate HTTP status code
+		return
+	}
+
+	token, err := h.auth.Issue(r.Context(), p)
+	if errors.Is(err, service.ErrUserDisabled) {
+		logger(r).Info("OIDC login by disabled user", "user", p.Username)                     // Log detailed error information
+		writeProblem(w, r, http.StatusForbidden, "user_disabled", "This account is disabled") // Return appropriate HTTP status code
+		return
+	}
+	if err != nil {
+		logger(r).Error("Error issuing token", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to log in") // Return appropriate HTTP status code
+		return
+	}
+	logger(r).Info("OIDC login", "user", p.Username, "role", p.Role)
+	writeToken(w, r, token)
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+// fakeOIDC logs in anyone presenting the code "good-code" with the nonce
+// it handed out.
+type fakeOIDC struct {
+	principal service.Principal
+	err       error
+}
+
+func (fakeOIDC) AuthCodeURL(state, nonce, verifier string) string {
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode()
+}
+
+func (f fakeOIDC) Exchange(_ context.Context, code, nonce, verifier string) (service.Principal, error) {
+	if code != "good-code" || nonce == "" || verifier == "" {
+		return service.Principal{}, service.ErrOIDCLogin
+	}
+	return f.principal, f.err
+}
+
+func newOIDCRouter(t *testing.T, oidc fakeOIDC) http.Handler {
+	t.Helper()
+
+	_, cars := newTestRouter(t)
+	return NewHandler(Services{
+		Auth: service.NewAuthService(cars, cars, testAuthConfig),
+		OIDC: oidc,
+	}, Config{}).Router()
+}
+
+// startOIDCLogin follows /auth/oidc/login and returns the state and the
+// cookie to present to the callback.
+func startOIDCLogin(t *testing.T, router http.Handler) (string, *http.Cookie) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: status %d, want %d", rec.Code, http.StatusFound)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || location.Host != "idp.example.com" {
+		t.Fatalf("redirected to %q, want the provider", rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oidcCookie || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %+v, want one HttpOnly %s", cookies, oidcCookie)
+	}
+	return location.Query().Get("state"), cookies[0]
+}
+
+func oidcCallback(router http.Handler, query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+query, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOIDCLogin(t *testing.T) {
+	router := newOIDCRouter(t, fakeOIDC{principal: service.Principal{Username: "staffer", Role: service.RoleAgent}})
+
+	state, cookie := startOIDCLogin(t, router)
+	rec := oidcCallback(router, "code=good-code&state="+state, cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"token_type":"Bearer"`) {
+		t.Fatalf("callback body = %s, want a bearer token", rec.Body)
+	}
+}
+
+func TestOIDCLoginDisabledUser(t *testing.T) {
+	_, cars := newTestRouter(t)
+	auth := service.NewAuthService(cars, cars, testAuthConfig)
+	staffer := service.Principal{Username: "staffer", Role: service.RoleAgent}
+	if _, err := auth.Issue(context.Background(), staffer); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if _, err := auth.DisableUser(context.Background(), "staffer"); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	router := NewHandler(Services{
+		Auth: auth,
+		OIDC: fakeOIDC{principal: staffer},
+	}, Config{}).Router()
+
+	state, cookie := startOIDCLogin(t, router)
+	if rec := oidcCallback(router, "code=good-code&state="+state, cookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("callback: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestOIDCCallbackErrors(t *testing.T) {
+	router := newOIDCRouter(t, fakeOIDC{principal: service.Principal{Username: "staffer", Role: service.RoleAgent}})
+	state, cookie := startOIDCLogin(t, router)
+
+	tests := []struct {
+		name   string
+		query  string
+		cookie *http.Cookie
+		want   int
+	}{
+		{"no cookie", "code=good-code&state=" + state, nil, http.StatusBadRequest},
+		{"wrong state", "code=good-code&state=forged", cookie, http.StatusBadRequest},
+		{"bad code", "code=bad-code&state=" + state, cookie, http.StatusUnauthorized},
+		{"provider error", "error=access_denied&state=" + state, cookie, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if rec := oidcCallback(router, tt.query, tt.cookie); rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	router = newOIDCRouter(t, fakeOIDC{err: service.ErrNoRole})
+	state, cookie = startOIDCLogin(t, router)
+	if rec := oidcCallback(router, "code=good-code&state="+state, cookie); rec.Code != http.StatusForbidden {
+		t.Errorf("no role: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestOIDCRoutesDisabledByDefault(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+// Listing headers. Version 1 bodies are bare arrays with no room for page
+// metadata.
+const (
+	// totalCountHeader carries the number of items on all pages.
+	totalCountHeader = "X-Total-Count"
+	// nextCursorHeader carries the cursor of the next page, if there is
+	// one.
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// encodeCursor returns the opaque cursor of the page after the car with
+// the given registration. Clients must not rely on its format.
+func encodeCursor(registration string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(registration))
+}
+
+// readPage sets the Limit and Offset of q from the limit and offset query
+// parameters, or its After from cursor. It writes a problem and returns
+// false if one is malformed.
+func readPage(w http.ResponseWriter, r *http.Request, q *store.CarQuery) bool {
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &q.Limit}, {"offset", &q.Offset}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			logger(r).Info("Invalid "+p.name, p.name, v)                                      // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid "+p.name) // Return appropriate HTTP status code
+			return false
+		}
+		*p.dst = n
+	}
+
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		return true
+	}
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(after) == 0 {
+		logger(r).Info("Invalid cursor", "cursor", cursor)                               // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid cursor") // Return appropriate HTTP status code
+		return false
+	}
+	if q.Offset > 0 {
+		logger(r).Info("Cursor with offset", "cursor", cursor, "offset", q.Offset)                    // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Use either cursor or offset") // Return appropriate HTTP status code
+		return false
+	}
+	q.After = string(after)
+	return true
+}
+
+// writePageHeaders announces the total size of a listing and links to the
+// neighbouring pages (RFC 8288), a page size apart. A request by offset
+// links to offsets, one by cursor to the next cursor. Only listings in
+// registration order get a cursor.
+func writePageHeaders(w http.ResponseWriter, r *http.Request, q store.CarQuery, page service.CarPage) {
+	w.Header().Set(totalCountHeader, strconv.Itoa(page.Total))
+	link := func(param, value, rel string) {
+		params := r.URL.Query()
+		params.Set(param, value)
+		w.Header().Add("Link", "<"+r.URL.Path+"?"+params.Encode()+`>; rel="`+rel+`"`)
+	}
+	if page.More {
+		if cursorOrder(q.Sort) {
+			cursor := encodeCursor(page.Cars[len(page.Cars)-1].Registration)
+			w.Header().Set(nextCursorHeader, cursor)
+			if q.After != "" {
+				link("cursor", cursor, "next")
+			}
+		}
+		if q.After == "" {
+			link("offset", strconv.Itoa(q.Offset+len(page.Cars)), "next")
+		}
+	}
+	if q.Offset > 0 {
+		link("offset", strconv.Itoa(max(q.Offset-page.Limit, 0)), "prev")
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// patchCar applies a JSON Merge Patch (RFC 7396) to a car. The model,
+// mileage and vehicle details can change. Model and mileage cannot be
+// removed, so null is refused for them like any other invalid value; for
+// a vehicle detail it means unknown. If-Match works as for updateCar.
+func (h *Handler) patchCar(w http.ResponseWriter, r *http.Request) {
+	registration := mux.Vars(r)["registration"]
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != mergePatchContentType && mediaType != "application/json") {
+			logger(r).Info("Unsupported patch format", "content_type", ct) // Log detailed error information
+			w.Header().Set("Accept-Patch", mergePatchContentType)
+			writeProblem(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", "Send an application/merge-patch+json body") // Return appropriate HTTP status code
+			return
+		}
+	}
+
+	var patch map[string]json.RawMessage
+	if !decodeJSON(w, r, &patch) {
+		return
+	}
+	if patch == nil {
+		logger(r).Info("Patch is not an object")                                             // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_body", "Patch must be an object") // Return appropriate HTTP status code
+		return
+	}
+
+	car, err := h.rentals.Car(r.Context(), registration, false)
+	switch {
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error querying data", "error", err)                                           // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve car") // Return appropriate HTTP status code
+		return
+	case version != 0 && car.Version != version:
+		writeCarModified(w, r, registration)
+		return
+	}
+	// Without If-Match the patch applies to whatever version the car has
+	// by the time it is written.
+	car.Version = version
+	if fields := applyCarPatch(&car, patch); len(fields) > 0 {
+		logger(r).Info("Invalid car patch", "registration", registration) // Log detailed error information
+		writeValidationProblem(w, r, "The patch is invalid", fields)      // Return appropriate HTTP status code
+		return
+	}
+
+	updated, err := h.rentals.UpdateCar(r.Context(), car)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid car", "error", err)                          // Log detailed error information
+		writeValidationProblem(w, r, "The patch is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarNotFound):
+		logger(r).Info("Car not found", "registration", registration)             // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "car_not_found", "Car not found") // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrCarModified):
+		writeCarModified(w, r, registration)
+		return
+	case err != nil:
+		logger(r).Error("Error updating database", "error", err)                                     // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update car") // Return appropriate HTTP status code
+		return
+	}
+
+	w.Header().Set("ETag", carETag(updated))
+	if err := encodeResponse(w, r, updated); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// applyCarPatch merges patch into car and returns the fields it could not
+// apply. Members left out of the patch keep their value; registration may
+// be repeated but not changed, rented changes only by renting and
+// returning, and needs_cleaning only through the cleaning workflow.
+// Unknown members are ignored, as in other request bodies.
+func applyCarPatch(car *store.Car, patch map[string]json.RawMessage) []service.FieldError {
+	var fields []service.FieldError
+	invalid := func(field string, err error) {
+		if err != nil {
+			fields = append(fields, service.FieldError{Field: field, Message: err.Error()})
+		}
+	}
+	if raw, ok := patch["model"]; ok {
+		invalid("model", decodeMember(raw, &car.Model))
+	}
+	if raw, ok := patch["mileage"]; ok {
+		invalid("mileage", decodeMember(raw, &car.Mileage))
+	}
+	for _, d := range []struct {
+		name string
+		dst  interface{}
+	}{
+		{"make", &car.Make}, {"year", &car.Year}, {"color", &car.Color}, {"vin", &car.VIN},
+		{"category", &car.Category}, {"transmission", &car.Transmission}, {"fuel", &car.Fuel}, {"seats", &car.Seats},
+	} {
+		if raw, ok := patch[d.name]; ok {
+			invalid(d.name, decodeDetail(raw, d.dst))
+		}
+	}
+	if raw, ok := patch["registration"]; ok {
+		var registration string
+		if decodeMember(raw, &registration) != nil || registration != car.Registration {
+			invalid("registration", errors.New("cannot be changed"))
+		}
+	}
+	if _, ok := patch["rented"]; ok {
+		invalid("rented", errors.New("cannot be changed; rent or return the car instead"))
+	}
+	if _, ok := patch["needs_cleaning"]; ok {
+		invalid("needs_cleaning", errors.New("cannot be changed; complete the cleaning task instead"))
+	}
+	return fields
+}
+
+var errNullMember = errors.New("cannot be removed")
+
+// decodeMember decodes a patch member into v. Null asks for the member to
+// be removed, which no car field allows.
+func decodeMember(raw json.RawMessage, v interface{}) error {
+	if string(raw) == "null" {
+		return errNullMember
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.New("has the wrong type")
+	}
+	return nil
+}
+
+// decodeDetail decodes a patch member into a vehicle detail, a *string or
+// *int. Null clears the detail.
+func decodeDetail(raw json.RawMessage, v interface{}) error {
+	if string(raw) != "null" {
+		return decodeMember(raw, v)
+	}
+	switch v := v.(type) {
+	case *string:
+		*v = ""
+	case *int:
+		*v = 0
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backendGo/internal/store"
+)
+
+func TestPatchVehicleDetails(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Civic","registration":"DEF456","mileage":3200,"make":"Honda","color":"red","seats":5}`)
+
+	rec := doRequest(t, router, http.MethodPatch, "/cars/DEF456", `{"color":null,"year":2021,"transmission":"manual"}`)
+	var car store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&car); err != nil {
+		t.Fatalf("decode car: %v", err)
+	}
+	want := store.Car{Model: "Civic", Registration: "DEF456", Mileage: 3200, Make: "Honda", Year: 2021, Transmission: "manual", Seats: 5}
+	if car != want {
+		t.Errorf("got %+v, want the color cleared and year and transmission set: %+v", car, want)
+	}
+}
+
+func TestPatchCar(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+
+	rec := doRequest(t, router, http.MethodPatch, "/cars/DEF456", `{"mileage":3150}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var car store.Car
+	if err := json.NewDecoder(rec.Body).Decode(&car); err != nil {
+		t.Fatalf("decode car: %v", err)
+	}
+	if want := (store.Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3150}); car != want {
+		t.Fatalf("got %+v, want only the mileage changed: %+v", car, want)
+	}
+
+	tests := []struct {
+		target, body string
+		want         int
+		fields       string
+	}{
+		{"/cars/DEF456", `{"model":null}`, http.StatusUnprocessableEntity, "model"},
+		{"/cars/DEF456", `{"mileage":"far"}`, http.StatusUnprocessableEntity, "mileage"},
+		{"/cars/DEF456", `{"mileage":-5}`, http.StatusUnprocessableEntity, "mileage"},
+		{"/cars/DEF456", `{"registration":"XYZ999","rented":true}`, http.StatusUnprocessableEntity, "registration,rented"},
+		{"/cars/DEF456", `{"registration":"DEF456","colour":"red"}`, http.StatusOK, ""},
+		{"/cars/DEF456", `{"year":"new","seats":null}`, http.StatusUnprocessableEntity, "year"},
+		{"/cars/DEF456", `{"vin":"NOTAVIN","fuel":"coal"}`, http.StatusUnprocessableEntity, "vin,fuel"},
+		{"/cars/DEF456", `null`, http.StatusBadRequest, ""},
+		{"/cars/DEF456", `[]`, http.StatusBadRequest, ""},
+		{"/cars/NOPE", `{"mileage":1}`, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, router, http.MethodPatch, tt.target, tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("PATCH %s %s: status %d, want %d: %s", tt.target, tt.body, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.fields == "" {
+			continue
+		}
+		var p problem
+		json.NewDecoder(rec.Body).Decode(&p)
+		var fields []string
+		for _, f := range p.Errors {
+			fields = append(fields, f.Field)
+		}
+		if got := strings.Join(fields, ","); got != tt.fields {
+			t.Errorf("PATCH %s %s: invalid fields %q, want %q", tt.target, tt.body, got, tt.fields)
+		}
+	}
+	if got := availableCars(t, router); len(got) != 1 || got[0] != car {
+		t.Fatalf("cars = %+v, want %+v unchanged by the refused patches", got, car)
+	}
+}
+
+func TestPatchCarContentType(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+
+	for contentType, want := range map[string]int{
+		"application/merge-patch+json": http.StatusOK,
+		"application/json":             http.StatusOK,
+		"application/json-patch+json":  http.StatusUnsupportedMediaType,
+	} {
+		req := httptest.NewRequest(http.MethodPatch, "/cars/DEF456", strings.NewReader(`{"mileage":3300}`))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Content-Type %s: status %d, want %d", contentType, rec.Code, want)
+		}
+		if want == http.StatusUnsupportedMediaType && rec.Header().Get("Accept-Patch") != mergePatchContentType {
+			t.Errorf("Content-Type %s: Accept-Patch = %q", contentType, rec.Header().Get("Accept-Patch"))
+		}
+	}
+}
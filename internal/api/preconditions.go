@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backendGo/internal/store"
+)
+
+// carETag returns the entity tag of car. It names the car's version, which
+// changes with every write, so both representations of one version share
+// it.
+func carETag(car store.Car) string {
+	return `"` + strconv.FormatInt(car.Version, 10) + `"`
+}
+
+// ifMatch returns the car version named by the If-Match header, or 0 if the
+// write is unconditional: no header, or "*". Only a single ETag from
+// carETag can match, as the store checks one version; any other header
+// fails the precondition.
+func ifMatch(w http.ResponseWriter, r *http.Request) (version int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	tag, quoted := strings.CutPrefix(header, `"`)
+	if quoted {
+		tag, quoted = strings.CutSuffix(tag, `"`)
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if !quoted || err != nil || version <= 0 {
+		logger(r).Info("Unmatchable If-Match", "if_match", header)                                                        // Log detailed error information
+		writeProblem(w, r, http.StatusPreconditionFailed, "precondition_failed", "If-Match names no version of this car") // Return appropriate HTTP status code
+		return 0, false
+	}
+	return version, true
+}
+
+// writeCarModified answers a conditional write that lost to another one.
+func writeCarModified(w http.ResponseWriter, r *http.Request, registration string) {
+	logger(r).Info("Car was modified", "registration", registration)                                            // Log detailed error information
+	writeProblem(w, r, http.StatusPreconditionFailed, "precondition_failed", "Car has changed; fetch it again") // Return appropriate HTTP status code
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConditionalCarWrites(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+
+	ifMatch := func(method, etag, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/cars/DEF456", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		req.Header.Set("If-Match", etag)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	etag := doRequest(t, router, http.MethodGet, "/cars/DEF456", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+	rec := ifMatch(http.MethodPatch, etag, `{"mileage":3300}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch at current ETag: status %d: %s", rec.Code, rec.Body)
+	}
+	next := rec.Header().Get("ETag")
+	if next == "" || next == etag {
+		t.Fatalf("patch returned ETag %q after %q, want a new one", next, etag)
+	}
+
+	for _, tt := range []struct {
+		method, etag, body string
+	}{
+		{http.MethodPut, etag, `{"model":"Honda Jazz","mileage":3300}`},
+		{http.MethodPatch, etag, `{"mileage":3400}`},
+		{http.MethodDelete, etag, ""},
+		{http.MethodDelete, "W/" + next, ""},
+		{http.MethodDelete, next + ", " + etag, ""},
+	} {
+		rec := ifMatch(tt.method, tt.etag, tt.body)
+		if rec.Code != http.StatusPreconditionFailed || !containsJSON(t, rec.Body.Bytes(), "code", "precondition_failed") {
+			t.Errorf("%s with If-Match %s: status %d: %s", tt.method, tt.etag, rec.Code, rec.Body)
+		}
+	}
+
+	// Renting is a write too.
+	doRequest(t, router, http.MethodPost, "/cars/DEF456/rentals", "")
+	if rec := ifMatch(http.MethodPut, next, `{"model":"Honda Jazz","mileage":3300}`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("put after renting: status %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+	if rec := ifMatch(http.MethodPut, "*", `{"model":"Honda Jazz","mileage":3300}`); rec.Code != http.StatusOK {
+		t.Errorf("put with If-Match *: status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"backendGo/internal/service"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem is an error response body in the format of RFC 9457 (which
+// obsoletes RFC 7807). Code is an extension member that names the error
+// for clients to branch on; detail is for people and may change.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Code     string `json:"code"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the invalid fields of a validation_failed problem.
+	Errors []service.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem returns the problem for status with the given code and
+// detail. Problems have no type URI of their own, so title is the status
+// text as RFC 9457 asks for "about:blank".
+func newProblem(status int, code, detail string) problem {
+	return problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// writeProblem replies to r with an application/problem+json error. Like
+// http.Error, it expects nothing else to have been written to w. Problems
+// are not versioned.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	renderProblem(w, r, newProblem(status, code, detail))
+}
+
+// renderProblem is writeProblem for problems with extension members.
+func renderProblem(w http.ResponseWriter, r *http.Request, p problem) {
+	p.Instance = r.URL.Path
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", problemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logger(r).Error("Error encoding problem response", "error", err)
+	}
+}
+
+// writeValidationProblem answers 422 Unprocessable Entity listing the
+// invalid fields.
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, detail string, fields []service.FieldError) {
+	p := newProblem(http.StatusUnprocessableEntity, "validation_failed", detail)
+	p.Errors = fields
+	renderProblem(w, r, p)
+}
+
+// notFound and methodNotAllowed answer requests the router has no route for.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, "not_found", "No such resource")
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed on this resource")
+}
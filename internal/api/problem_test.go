@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProblemResponses(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"ABC123","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/cars/ABC123/rentals", "")
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		code                 string
+	}{
+		{http.MethodPost, "/cars/ABC123/rentals", "", http.StatusBadRequest, "car_already_rented"},
+		{http.MethodPost, "/cars/NOPE/rentals", "", http.StatusNotFound, "car_not_found"},
+		{http.MethodPost, "/cars/NOPE/returns?mileage=x", "", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodPut, "/users/nobody/role", `{"role":"agent"}`, http.StatusNotFound, "user_not_found"},
+		{http.MethodPost, "/cars", `{"mileage":-1}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{http.MethodGet, "/nowhere", "", http.StatusNotFound, "not_found"},
+		{http.MethodDelete, "/cars", "", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	schema := compileSchema(t, "problem.json")
+	for _, tt := range tests {
+		rec := doRequest(t, router, tt.method, tt.target, tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+			t.Errorf("%s %s: content type %q, want %q", tt.method, tt.target, ct, problemContentType)
+		}
+		var raw interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+			t.Errorf("%s %s: decode: %v", tt.method, tt.target, err)
+			continue
+		}
+		if err := schema.Validate(raw); err != nil {
+			t.Errorf("%s %s does not match problem.json: %v", tt.method, tt.target, err)
+		}
+		var p problem
+		path, _, _ := strings.Cut(tt.target, "?")
+		json.Unmarshal(rec.Body.Bytes(), &p)
+		if p.Code != tt.code || p.Status != tt.status || p.Instance != path {
+			t.Errorf("%s %s: problem %+v, want code %s", tt.method, tt.target, p, tt.code)
+		}
+	}
+}
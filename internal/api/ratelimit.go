@@ -0,0 +1,152 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit configures per-client token buckets. Each client may make
+// Burst requests at once and RequestsPerSecond on average.
+type RateLimit struct {
+	// RequestsPerSecond is the refill rate. Zero disables rate limiting.
+	RequestsPerSecond float64
+	Burst             int
+	// ClientIPHeader names a header set by a trusted reverse proxy, such as
+	// X-Forwarded-For or X-Real-IP, to take the client address from. Empty
+	// uses the connection's remote address.
+	ClientIPHeader string
+}
+
+// bucketIdleSweep is how often buckets that have refilled completely are
+// dropped; a full bucket behaves exactly like a missing one.
+const bucketIdleSweep = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter holds one token bucket per client key.
+type rateLimiter struct {
+	config RateLimit
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(config RateLimit) *rateLimiter {
+	return &rateLimiter{config: config, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from the bucket of key. It returns the tokens left and,
+// if none was available, how long until one is.
+func (l *rateLimiter) allow(key string) (ok bool, remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	burst := float64(l.config.Burst)
+	if now.Sub(l.lastSweep) >= bucketIdleSweep {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.config.RequestsPerSecond >= burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.config.RequestsPerSecond)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) / l.config.RequestsPerSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// untilFull returns how long a bucket with remaining tokens takes to refill.
+func (l *rateLimiter) untilFull(remaining int) time.Duration {
+	return time.Duration(float64(l.config.Burst-remaining) / l.config.RequestsPerSecond * float64(time.Second))
+}
+
+// limitRate rejects requests beyond the client address's rate limit with 429
+// Too Many Requests. It runs before authenticate, so a flood of requests,
+// signed tokens included, is refused before any session is looked up.
+// Probes and metrics scrapes are never limited.
+func (h *Handler) limitRate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.limiter == nil || unlimited(r) || h.throttle(w, r, "ip:"+clientIP(r, h.limiter.config.ClientIPHeader)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// limitUsers additionally limits authenticated requests per user, so that
+// one user cannot spend the budget of others at the same address nor escape
+// their own by switching addresses.
+func (h *Handler) limitUsers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := principal(r)
+		if h.limiter == nil || !ok || unlimited(r) || h.throttle(w, r, "user:"+p.Username) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func unlimited(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/metrics", "/healthz", "/readyz":
+		return true
+	}
+	return false
+}
+
+// throttle takes a token from the bucket of key and sets the rate limit
+// headers. If the bucket is empty it writes 429 and returns false.
+func (h *Handler) throttle(w http.ResponseWriter, r *http.Request, key string) bool {
+	ok, remaining, retryAfter := h.limiter.allow(key)
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(h.limiter.config.Burst))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(h.limiter.untilFull(remaining))))
+	if !ok {
+		logger(r).Info("Rate limited", "client", key)
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+		writeProblem(w, r, http.StatusTooManyRequests, "rate_limited", "Too many requests")
+	}
+	return ok
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientIP returns the address of the client, from header if set. Of a
+// comma-separated list such as X-Forwarded-For, the last address is used:
+// it was added by the trusted proxy, while earlier ones come from the client
+// and can be forged.
+func clientIP(r *http.Request, header string) string {
+	if header != "" {
+		if v := r.Header.Get(header); v != "" {
+			return strings.TrimSpace(v[strings.LastIndex(v, ",")+1:])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backendGo/internal/service"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimit{RequestsPerSecond: 2, Burst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 2; i >= 0; i-- {
+		if ok, remaining, _ := l.allow("a"); !ok || remaining != i {
+			t.Fatalf("request %d: allowed %v with %d left, want %d left", 3-i, ok, remaining, i)
+		}
+	}
+	ok, _, retryAfter := l.allow("a")
+	if ok || retryAfter != 500*time.Millisecond {
+		t.Fatalf("over the burst: allowed %v, retry after %v, want refused for 500ms", ok, retryAfter)
+	}
+	if ok, _, _ := l.allow("b"); !ok {
+		t.Fatal("another client was limited")
+	}
+
+	now = now.Add(time.Second)
+	if ok, remaining, _ := l.allow("a"); !ok || remaining != 1 {
+		t.Fatalf("after 1s: allowed %v with %d left, want 1 left", ok, remaining)
+	}
+
+	// Buckets that have refilled are swept.
+	now = now.Add(bucketIdleSweep)
+	l.allow("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Fatal("idle bucket was not swept")
+	}
+}
+
+func TestLimitRate(t *testing.T) {
+	_, cars := newTestRouter(t)
+	router := NewHandler(Services{
+		Rentals: service.NewRentalService(cars),
+		Health:  service.NewHealthService(cars),
+		Auth:    fakeAuth{},
+	}, Config{RateLimit: RateLimit{RequestsPerSecond: 0.1, Burst: 2, ClientIPHeader: "X-Forwarded-For"}}).Router()
+
+	get := func(target, token, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := get("/cars", "", ""); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: status %d, limit %q", i+1, rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+	rec := get("/cars", "", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the limit: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("Retry-After") != "10" || rec.Header().Get("X-RateLimit-Remaining") != "0" || rec.Header().Get("X-RateLimit-Reset") != "20" {
+		t.Fatalf("headers = %v, want Retry-After 10, none remaining, reset in 20s", rec.Header())
+	}
+
+	// Addresses behind the proxy and probes have their own budgets.
+	if rec := get("/cars", "", "203.0.113.7, 198.51.100.1"); rec.Code != http.StatusOK {
+		t.Fatalf("other forwarded address: status %d", rec.Code)
+	}
+	if rec := get("/healthz", "", ""); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("health check: status %d, limit %q, want it unlimited", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+	// Tokens, valid or not, do not escape the address limit.
+	if rec := get("/cars", "forged", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("invalid token: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec := get("/cars", agentToken, ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("valid token: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	// A user is also limited across addresses.
+	for i, addr := range []string{"203.0.113.8", "203.0.113.9", "203.0.113.10"} {
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if rec := get("/cars", agentToken, addr); rec.Code != want {
+			t.Fatalf("user request %d from %s: status %d, want %d", i+1, addr, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+)
+
+// schemaFiles holds the JSON Schema (draft 2020-12) of every JSON response
+// body, one file per payload. Schemas reference each other by file name,
+// so they resolve relative to wherever they are served from.
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+const schemaContentType = "application/schema+json"
+
+// listSchemas returns the names of the published schemas.
+func (h *Handler) listSchemas(w http.ResponseWriter, r *http.Request) {
+	files, err := fs.Glob(schemaFiles, "schemas/*.json")
+	if err != nil {
+		logger(r).Error("Error listing schemas", "error", err)                                         // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to list schemas") // Return appropriate HTTP status code
+		return
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = path.Base(f)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(names); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)
+	}
+}
+
+func (h *Handler) getSchema(w http.ResponseWriter, r *http.Request) {
+	data, err := schemaFiles.ReadFile("schemas/" + mux.Vars(r)["name"])
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, "schema_not_found", "Schema not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", schemaContentType)
+	w.Write(data)
+}
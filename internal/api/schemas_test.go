@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaBase is where the test compiler pretends the schemas are served,
+// so their relative references resolve to each other.
+const schemaBase = "https://carrental.test/schemas/"
+
+func compileSchema(t *testing.T, name string) *jsonschema.Schema {
+	t.Helper()
+
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	files, _ := fs.Glob(schemaFiles, "schemas/*.json")
+	for _, f := range files {
+		data, _ := schemaFiles.ReadFile(f)
+		if err := c.AddResource(schemaBase+f[len("schemas/"):], bytes.NewReader(data)); err != nil {
+			t.Fatalf("add schema %s: %v", f, err)
+		}
+	}
+	s, err := c.Compile(schemaBase + name)
+	if err != nil {
+		t.Fatalf("compile %s: %v", name, err)
+	}
+	return s
+}
+
+func TestResponsesMatchSchemas(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+	doRequest(t, router, http.MethodPost, "/users", `{"username":"bob","password":"pw","role":"agent"}`)
+
+	tests := []struct {
+		method, target, body, schema string
+	}{
+		{http.MethodGet, "/cars", "", "cars.json"},
+		{http.MethodGet, "/cars/DEF456", "", "car.json"},
+		{http.MethodGet, "/cars/search?q=honda", "", "cars.json"},
+		{http.MethodPut, "/cars/DEF456", `{"model":"Honda Civic","mileage":3201}`, "car.json"},
+		{http.MethodPatch, "/cars/DEF456", `{"mileage":3202}`, "car.json"},
+		{http.MethodPost, "/cars", `{"model":"Tesla M3","registration":"BTS812","mileage":6003,"make":"Tesla","year":2023,"color":"white","vin":"1HGCM82633A004352","category":"luxury","transmission":"automatic","fuel":"electric","seats":5}`, "message.json"},
+		{http.MethodGet, "/cars/BTS812", "", "car.json"},
+		{http.MethodPost, "/cars/DEF456/rentals", "", "message.json"},
+		{http.MethodPost, "/cars/DEF456/returns?mileage=10", "", "message.json"},
+		{http.MethodGet, "/cleaning/tasks", "", "cleaning-tasks.json"},
+		{http.MethodPut, "/cleaning/tasks/1/assignee", `{"assignee":"bob"}`, "cleaning-task.json"},
+		{http.MethodPost, "/cleaning/tasks/1/completion", "", "cleaning-task.json"},
+		{http.MethodDelete, "/cars/BTS812", "", "message.json"},
+		{http.MethodGet, "/cars?include_deleted=true", "", "cars.json"},
+		{http.MethodPost, "/cars/BTS812/restoration", "", "car.json"},
+		{http.MethodGet, "/changes", "", "changes-page.json"},
+		{http.MethodGet, "/categories", "", "categories.json"},
+		{http.MethodPost, "/categories", `{"name":"compact","description":"Small cars"}`, "message.json"},
+		{http.MethodPut, "/categories/compact", `{"description":"Small city cars"}`, "category.json"},
+		{http.MethodGet, "/categories/availability", "", "category-availability.json"},
+		{http.MethodDelete, "/categories/compact", "", "message.json"},
+		{http.MethodGet, "/cars/availability/poll", "", "availability-poll.json"},
+		{http.MethodGet, "/users", "", "users.json"},
+		{http.MethodGet, "/auth/sessions", "", "sessions.json"},
+		{http.MethodPost, "/users", `{"username":"eve","password":"pw"}`, "message.json"},
+		{http.MethodGet, "/healthz", "", "health.json"},
+		{http.MethodGet, "/readyz", "", "health.json"},
+	}
+	for _, tt := range tests {
+		rec := doRequest(t, router, tt.method, tt.target, tt.body)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s %s: status %d: %s", tt.method, tt.target, rec.Code, rec.Body)
+			continue
+		}
+		var body interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: decode: %v", tt.method, tt.target, err)
+			continue
+		}
+		if err := compileSchema(t, tt.schema).Validate(body); err != nil {
+			t.Errorf("%s %s does not match %s: %v", tt.method, tt.target, tt.schema, err)
+		}
+	}
+}
+
+func TestSchemaEndpoints(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodGet, "/schemas", "")
+	var names []string
+	if err := json.NewDecoder(rec.Body).Decode(&names); err != nil || len(names) == 0 {
+		t.Fatalf("GET /schemas: %v, names %v", err, names)
+	}
+	for _, name := range names {
+		rec := doRequest(t, router, http.MethodGet, "/schemas/"+name, "")
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != schemaContentType {
+			t.Errorf("GET /schemas/%s: status %d, content type %q", name, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		compileSchema(t, name)
+	}
+
+	if rec := doRequest(t, router, http.MethodGet, "/schemas/nope.json", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown schema: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
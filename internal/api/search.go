@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+// searchCars finds cars by words of their model or registration, for staff
+// at the counter who do not have the exact registration to hand.
+func (h *Handler) searchCars(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			logger(r).Info("Invalid limit", "limit", v)                                     // Log detailed error information
+			writeProblem(w, r, http.StatusBadRequest, "invalid_parameter", "Invalid limit") // Return appropriate HTTP status code
+			return
+		}
+		limit = n
+	}
+
+	cars, err := h.rentals.SearchCars(r.Context(), r.URL.Query().Get("q"), limit)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid search", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The search is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error querying data", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to search cars") // Return appropriate HTTP status code
+		return
+	}
+	if cars == nil {
+		cars = []store.Car{}
+	}
+
+	if err := encodeResponse(w, r, cars); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
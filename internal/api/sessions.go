@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"backendGo/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// logout ends the session of the access token used to call it.
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	p, _ := principal(r)
+	err := h.auth.Logout(r.Context(), p.SessionID)
+	if err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+		logger(r).Error("Error revoking session", "error", err)                                   // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to log out") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Logged out successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// listSessions lists the caller's active sessions. Admins can list anyone's
+// with ?username=.
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	p, _ := principal(r)
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		username = p.Username
+	}
+	if username != p.Username && p.Role != service.RoleAdmin {
+		logger(r).Info("Forbidden", "role", p.Role, "username", username)                                // Log detailed error information
+		writeProblem(w, r, http.StatusForbidden, "insufficient_permissions", "Insufficient permissions") // Return appropriate HTTP status code
+		return
+	}
+
+	sessions, err := h.auth.Sessions(r.Context(), username)
+	if err != nil {
+		logger(r).Error("Error querying sessions", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve sessions") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, sessions); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	p, _ := principal(r)
+	id := mux.Vars(r)["id"]
+
+	err := h.auth.RevokeSession(r.Context(), p, id)
+	switch {
+	case errors.Is(err, service.ErrSessionNotFound):
+		logger(r).Info("Session not found", "session", id)                                // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "session_not_found", "Session not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error revoking session", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to revoke session") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Session revoked successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// revokeUserSessions signs a user out everywhere, such as when they leave.
+func (h *Handler) revokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	n, err := h.auth.RevokeUserSessions(r.Context(), username)
+	if err != nil {
+		logger(r).Error("Error revoking sessions", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to revoke sessions") // Return appropriate HTTP status code
+		return
+	}
+	logger(r).Info("Revoked sessions", "username", username, "count", n)
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Sessions revoked successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
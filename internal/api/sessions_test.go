@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+// newSessionRouter returns a router that issues real tokens, with an admin
+// "alice" and an agent "bob".
+func newSessionRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	_, cars := newTestRouter(t)
+	auth := service.NewAuthService(cars, cars, testAuthConfig)
+	for name, role := range map[string]service.Role{"alice": service.RoleAdmin, "bob": service.RoleAgent} {
+		if err := auth.CreateUser(context.Background(), name, "correct horse", role); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	return NewHandler(Services{Rentals: service.NewRentalService(cars), Auth: auth}, Config{}).Router()
+}
+
+// call serves a request with an optional bearer token.
+func call(t *testing.T, router http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeToken(t *testing.T, rec *httptest.ResponseRecorder) tokenResponse {
+	t.Helper()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token request: status %d: %s", rec.Code, rec.Body)
+	}
+	var token tokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&token); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	if token.AccessToken == "" || token.RefreshToken == "" {
+		t.Fatalf("token = %+v, want access and refresh tokens", token)
+	}
+	return token
+}
+
+func loginAs(t *testing.T, router http.Handler, username string) tokenResponse {
+	t.Helper()
+	return decodeToken(t, call(t, router, http.MethodPost, "/auth/login", "", `{"username":"`+username+`","password":"correct horse"}`))
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	router := newSessionRouter(t)
+	first := loginAs(t, router, "bob")
+
+	second := decodeToken(t, call(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+first.RefreshToken+`"}`))
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+	if rec := call(t, router, http.MethodPost, "/cars/X/rentals", second.AccessToken, ""); rec.Code == http.StatusUnauthorized {
+		t.Fatalf("refreshed access token rejected: %s", rec.Body)
+	}
+
+	rec := call(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+first.RefreshToken+`"}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions", second.AccessToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("access token after reuse: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	router := newSessionRouter(t)
+	token := loginAs(t, router, "bob")
+
+	if rec := call(t, router, http.MethodPost, "/auth/logout", token.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("logout: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions", token.AccessToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("access token after logout: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	rec := call(t, router, http.MethodPost, "/auth/refresh", "", `{"refresh_token":"`+token.RefreshToken+`"}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	router := newSessionRouter(t)
+	admin := loginAs(t, router, "alice")
+	bob := loginAs(t, router, "bob")
+	loginAs(t, router, "bob")
+
+	listSessions := func(token, target string) []service.Session {
+		t.Helper()
+		rec := call(t, router, http.MethodGet, target, token, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body)
+		}
+		var sessions []service.Session
+		if err := json.NewDecoder(rec.Body).Decode(&sessions); err != nil {
+			t.Fatalf("decode sessions: %v", err)
+		}
+		return sessions
+	}
+
+	own := listSessions(bob.AccessToken, "/auth/sessions")
+	if len(own) != 2 || own[0].Username != "bob" {
+		t.Fatalf("bob's sessions = %+v, want two", own)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions?username=alice", bob.AccessToken, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("agent listing another user's sessions: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	adminSessions := listSessions(admin.AccessToken, "/auth/sessions")
+	if rec := call(t, router, http.MethodDelete, "/auth/sessions/"+adminSessions[0].ID, bob.AccessToken, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("agent revoking an admin session: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	if rec := call(t, router, http.MethodDelete, "/users/bob/sessions", bob.AccessToken, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("agent revoking all sessions: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := call(t, router, http.MethodDelete, "/users/bob/sessions", admin.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("revoke bob's sessions: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions", bob.AccessToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bob's token after revocation: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if got := listSessions(admin.AccessToken, "/auth/sessions?username=bob"); len(got) != 0 {
+		t.Fatalf("bob's sessions after revocation = %+v, want none", got)
+	}
+	if rec := call(t, router, http.MethodPost, "/auth/login", "", `{"username":"bob","password":"correct horse"}`); rec.Code != http.StatusOK {
+		t.Fatalf("login after revocation: status %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := call(t, router, http.MethodDelete, "/auth/sessions/"+adminSessions[0].ID, admin.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("revoke own session: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions", admin.AccessToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("admin token after revoking own session: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestDisableUserEndpoints(t *testing.T) {
+	router := newSessionRouter(t)
+	admin := loginAs(t, router, "alice")
+	bob := loginAs(t, router, "bob")
+	login := func() int {
+		return call(t, router, http.MethodPost, "/auth/login", "", `{"username":"bob","password":"correct horse"}`).Code
+	}
+
+	if rec := call(t, router, http.MethodPut, "/users/bob/disabled", bob.AccessToken, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("agent disabling a user: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		if rec := call(t, router, method, "/users/nobody/disabled", admin.AccessToken, ""); rec.Code != http.StatusNotFound {
+			t.Fatalf("%s for an unknown user: status %d, want %d", method, rec.Code, http.StatusNotFound)
+		}
+	}
+
+	if rec := call(t, router, http.MethodPut, "/users/bob/disabled", admin.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("disable bob: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := call(t, router, http.MethodGet, "/auth/sessions", bob.AccessToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bob's token after disabling: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if code := login(); code != http.StatusForbidden {
+		t.Fatalf("login as disabled bob: status %d, want %d", code, http.StatusForbidden)
+	}
+
+	if rec := call(t, router, http.MethodDelete, "/users/bob/disabled", admin.AccessToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("enable bob: status %d: %s", rec.Code, rec.Body)
+	}
+	if code := login(); code != http.StatusOK {
+		t.Fatalf("login after enabling: status %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"backendGo/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+type createUserRequest struct {
+	Username string       `json:"username"`
+	Password string       `json:"password"`
+	Role     service.Role `json:"role"`
+}
+
+type setRoleRequest struct {
+	Role service.Role `json:"role"`
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.auth.ListUsers(r.Context())
+	if err != nil {
+		logger(r).Error("Error querying users", "error", err)                                            // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to retrieve users") // Return appropriate HTTP status code
+		return
+	}
+	if users == nil {
+		users = []service.User{}
+	}
+
+	if err := encodeResponse(w, r, users); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = service.RoleCustomer
+	}
+
+	err := h.auth.CreateUser(r.Context(), req.Username, req.Password, req.Role)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		logger(r).Info("Invalid user", "error", err)                        // Log detailed error information
+		writeValidationProblem(w, r, "The user is invalid", invalid.Fields) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrInvalidRole):
+		logger(r).Info("Invalid role", "role", req.Role)                       // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_role", err.Error()) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrUserExists):
+		logger(r).Info("User already exists", "username", req.Username)               // Log detailed error information
+		writeProblem(w, r, http.StatusConflict, "user_exists", "User already exists") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error creating user", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to create user") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "User created successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+func (h *Handler) setUserRole(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	var req setRoleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	err := h.auth.SetRole(r.Context(), username, req.Role)
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		logger(r).Info("Invalid role", "role", req.Role)                       // Log detailed error information
+		writeProblem(w, r, http.StatusBadRequest, "invalid_role", err.Error()) // Return appropriate HTTP status code
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		logger(r).Info("User not found", "username", username)                      // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "user_not_found", "User not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error updating user", "error", err)                                               // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to update user role") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "Role updated successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// disableUser locks a user out, as when they leave: they can no longer log
+// in or refresh, and their sessions end.
+func (h *Handler) disableUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	n, err := h.auth.DisableUser(r.Context(), username)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		logger(r).Info("User not found", "username", username)                      // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "user_not_found", "User not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error disabling user", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to disable user") // Return appropriate HTTP status code
+		return
+	}
+	logger(r).Info("Disabled user", "username", username, "revoked_sessions", n)
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "User disabled successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
+
+// enableUser lets a disabled user log in again.
+func (h *Handler) enableUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	err := h.auth.EnableUser(r.Context(), username)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		logger(r).Info("User not found", "username", username)                      // Log detailed error information
+		writeProblem(w, r, http.StatusNotFound, "user_not_found", "User not found") // Return appropriate HTTP status code
+		return
+	case err != nil:
+		logger(r).Error("Error enabling user", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to enable user") // Return appropriate HTTP status code
+		return
+	}
+
+	if err := encodeResponse(w, r, map[string]interface{}{"message": "User enabled successfully"}); err != nil {
+		logger(r).Error("Error encoding JSON response", "error", err)                                          // Log detailed error information
+		writeProblem(w, r, http.StatusInternalServerError, "internal_error", "Failed to encode JSON response") // Return appropriate HTTP status code
+		return
+	}
+}
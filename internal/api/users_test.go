@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"backendGo/internal/service"
+)
+
+func listUsers(t *testing.T, router http.Handler) []service.User {
+	t.Helper()
+
+	rec := doRequest(t, router, http.MethodGet, "/users", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /users: status %d: %s", rec.Code, rec.Body)
+	}
+	var users []service.User
+	if err := json.NewDecoder(rec.Body).Decode(&users); err != nil {
+		t.Fatalf("decode users: %v", err)
+	}
+	return users
+}
+
+func TestManageUsers(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	if users := listUsers(t, router); len(users) != 0 {
+		t.Fatalf("users before create = %+v, want none", users)
+	}
+
+	rec := doRequest(t, router, http.MethodPost, "/users", `{"username":"bob","password":"pw"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create user: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doRequest(t, router, http.MethodPost, "/users", `{"username":"bob","password":"pw"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("create duplicate: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if users := listUsers(t, router); len(users) != 1 || users[0].Username != "bob" || users[0].Role != service.RoleCustomer {
+		t.Fatalf("users = %+v, want bob as customer", users)
+	}
+
+	rec = doRequest(t, router, http.MethodPut, "/users/bob/role", `{"role":"agent"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set role: status %d: %s", rec.Code, rec.Body)
+	}
+	if users := listUsers(t, router); users[0].Role != service.RoleAgent {
+		t.Fatalf("role after update = %q, want agent", users[0].Role)
+	}
+}
+
+func TestManageUsersErrors(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/users", `{"username":"bob","password":"pw"}`)
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/users", `{"username":"eve","password":"pw","role":"owner"}`, http.StatusBadRequest},
+		{http.MethodPost, "/users", `not json`, http.StatusBadRequest},
+		{http.MethodPut, "/users/bob/role", `{"role":"owner"}`, http.StatusBadRequest},
+		{http.MethodPut, "/users/nobody/role", `{"role":"agent"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(t, router, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s: status %d, want %d", tt.method, tt.target, tt.body, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestCreateInvalidUser(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	rec := doRequest(t, router, http.MethodPost, "/users", `{"role":"agent"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+	}
+	var p problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	var fields []string
+	for _, f := range p.Errors {
+		fields = append(fields, f.Field)
+	}
+	if p.Code != "validation_failed" || strings.Join(fields, ",") != "username,password" {
+		t.Fatalf("problem = %+v, want validation_failed on username and password", p)
+	}
+	if users := listUsers(t, router); len(users) != 0 {
+		t.Fatalf("invalid user was created: %+v", users)
+	}
+}
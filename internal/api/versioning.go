@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Representations are versioned by media type rather than by path, so one
+// resource can change shape without forking the route tree. Clients opt in
+// with e.g. Accept: application/vnd.carrental.v2+json; anything else gets
+// version 1, the original representation.
+//
+//	v1: the bare resource, e.g. [{"model": ...}]
+//	v2: the resource wrapped in an envelope, e.g. {"data": [{"model": ...}]}
+const (
+	defaultVersion = 1
+	latestVersion  = 2
+)
+
+// vendorMediaType returns the media type of representation version v.
+func vendorMediaType(v int) string {
+	return fmt.Sprintf("application/vnd.carrental.v%d+json", v)
+}
+
+// parseVendorVersion returns the version of a carrental vendor media type.
+func parseVendorVersion(mediaType string) (int, bool) {
+	rest, ok := strings.CutPrefix(mediaType, "application/vnd.carrental.v")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, "+json")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(rest)
+	return v, err == nil && v > 0
+}
+
+type versionKey struct{}
+
+// negotiated is the outcome of negotiateVersion.
+type negotiated struct {
+	version int
+	// vendor reports whether the client asked for a vendor media type.
+	vendor bool
+}
+
+// representation returns the version negotiated by negotiateVersion.
+func representation(r *http.Request) negotiated {
+	if n, ok := r.Context().Value(versionKey{}).(negotiated); ok {
+		return n
+	}
+	return negotiated{version: defaultVersion}
+}
+
+// negotiateVersion picks the representation version from the Accept
+// header: the supported vendor version with the highest quality, or
+// version 1 if no vendor type is listed. A request that only accepts
+// unsupported vendor versions gets 406 Not Acceptable.
+func negotiateVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		var chosen negotiated
+		bestQ := -1.0
+		vendorOnly := true
+		for _, accept := range r.Header.Values("Accept") {
+			for _, part := range strings.Split(accept, ",") {
+				mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+				if err != nil {
+					continue
+				}
+				q := 1.0
+				if qs, ok := params["q"]; ok {
+					if q, err = strconv.ParseFloat(qs, 64); err != nil {
+						continue
+					}
+				}
+				v, ok := parseVendorVersion(mediaType)
+				if !ok {
+					vendorOnly = false
+					if q > 0 && q > bestQ {
+						chosen, bestQ = negotiated{version: defaultVersion}, q
+					}
+					continue
+				}
+				if v <= latestVersion && q > 0 && q > bestQ {
+					chosen, bestQ = negotiated{version: v, vendor: true}, q
+				}
+			}
+		}
+		if chosen.version == 0 {
+			if vendorOnly && r.Header.Get("Accept") != "" {
+				writeProblem(w, r, http.StatusNotAcceptable, "unsupported_version", fmt.Sprintf("Unsupported representation version, latest is %s", vendorMediaType(latestVersion)))
+				return
+			}
+			chosen.version = defaultVersion
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, chosen)))
+	})
+}
+
+// envelope wraps version 2 representations.
+type envelope struct {
+	Data interface{} `json:"data"`
+}
+
+// encodeResponse writes v in the negotiated representation version. The
+// vendor media type is declared only to clients that asked for one, so
+// existing clients see unchanged responses.
+func encodeResponse(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	n := representation(r)
+	if n.vendor {
+		w.Header().Set("Content-Type", vendorMediaType(n.version))
+	}
+	if n.version == 1 {
+		return json.NewEncoder(w).Encode(v)
+	}
+	return json.NewEncoder(w).Encode(envelope{Data: v})
+}
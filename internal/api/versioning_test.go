@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		accept      string
+		wantCode    int
+		wantVersion int
+		wantType    string
+	}{
+		{"", http.StatusOK, 1, ""},
+		{"application/json", http.StatusOK, 1, ""},
+		{"*/*", http.StatusOK, 1, ""},
+		{"application/vnd.carrental.v1+json", http.StatusOK, 1, "application/vnd.carrental.v1+json"},
+		{"application/vnd.carrental.v2+json", http.StatusOK, 2, "application/vnd.carrental.v2+json"},
+		{"application/vnd.carrental.v2+json;q=0.5, application/json", http.StatusOK, 1, ""},
+		{"application/json;q=0.5, application/vnd.carrental.v2+json", http.StatusOK, 2, "application/vnd.carrental.v2+json"},
+		{"application/vnd.carrental.v9+json, application/vnd.carrental.v2+json;q=0.1", http.StatusOK, 2, "application/vnd.carrental.v2+json"},
+		{"application/vnd.carrental.v9+json, */*;q=0.1", http.StatusOK, 1, ""},
+		{"application/vnd.carrental.v9+json", http.StatusNotAcceptable, 0, ""},
+	}
+	for _, tt := range tests {
+		var got negotiated
+		h := negotiateVersion(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = representation(r)
+			encodeResponse(w, r, []string{})
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("Accept %q: status %d, want %d", tt.accept, rec.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+		if got.version != tt.wantVersion {
+			t.Errorf("Accept %q: version %d, want %d", tt.accept, got.version, tt.wantVersion)
+		}
+		if tt.wantType != "" && rec.Header().Get("Content-Type") != tt.wantType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, rec.Header().Get("Content-Type"), tt.wantType)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", tt.accept, rec.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCarsV2Envelope(t *testing.T) {
+	router, _ := newTestRouter(t)
+	doRequest(t, router, http.MethodPost, "/cars", `{"model":"Honda Civic","registration":"DEF456","mileage":3200}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/cars", nil)
+	req.Header.Set("Accept", "application/vnd.carrental.v2+json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if err := compileSchema(t, "envelope.json").Validate(body); err != nil {
+		t.Fatalf("response does not match envelope.json: %v", err)
+	}
+	data := body.(map[string]interface{})["data"]
+	if err := compileSchema(t, "cars.json").Validate(data); err != nil {
+		t.Fatalf("data does not match cars.json: %v", err)
+	}
+	if cars := data.([]interface{}); len(cars) != 1 {
+		t.Fatalf("data = %v, want one car", data)
+	}
+}
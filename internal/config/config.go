@@ -0,0 +1,511 @@
+// Package config loads the server configuration from defaults, an optional
+// YAML or JSON file, environment variables and command-line flags, in
+// increasing order of precedence.
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes every environment variable read by Load.
+const EnvPrefix = "CARRENTAL_"
+
+// Config is the server configuration.
+type Config struct {
+	ListenAddr string    `json:"listen_addr" yaml:"listen_addr"`
+	LogLevel   string    `json:"log_level" yaml:"log_level"`
+	Database   Database  `json:"database" yaml:"database"`
+	Server     Server    `json:"server" yaml:"server"`
+	Tracing    Tracing   `json:"tracing" yaml:"tracing"`
+	Auth       Auth      `json:"auth" yaml:"auth"`
+	OIDC       OIDC      `json:"oidc" yaml:"oidc"`
+	RateLimit  RateLimit `json:"rate_limit" yaml:"rate_limit"`
+	CORS       CORS      `json:"cors" yaml:"cors"`
+	Cleaning   Cleaning  `json:"cleaning" yaml:"cleaning"`
+}
+
+// Database configures the storage backend.
+type Database struct {
+	Driver          string   `json:"driver" yaml:"driver"`
+	DSN             string   `json:"dsn" yaml:"dsn"`
+	MaxOpenConns    int      `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+}
+
+// Server configures the HTTP server timeouts and request limits.
+type Server struct {
+	ReadHeaderTimeout Duration `json:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// HandlerTimeout bounds the time spent serving one request, including
+	// database queries. It should be shorter than WriteTimeout so that the
+	// client gets an answer.
+	HandlerTimeout Duration `json:"handler_timeout" yaml:"handler_timeout"`
+	// MaxBodyBytes bounds the size of request bodies.
+	MaxBodyBytes int64 `json:"max_body_bytes" yaml:"max_body_bytes"`
+}
+
+// Tracing configures OpenTelemetry trace export. Export is disabled while
+// OTLPEndpoint is empty.
+type Tracing struct {
+	ServiceName  string  `json:"service_name" yaml:"service_name"`
+	OTLPEndpoint string  `json:"otlp_endpoint" yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `json:"otlp_insecure" yaml:"otlp_insecure"`
+	SampleRatio  float64 `json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// Auth configures token-based authentication of mutating endpoints.
+type Auth struct {
+	// JWTSigningKey signs access tokens. A random key is generated at
+	// startup when it is empty, so tokens do not survive a restart.
+	JWTSigningKey string   `json:"jwt_signing_key" yaml:"jwt_signing_key"`
+	TokenTTL      Duration `json:"token_ttl" yaml:"token_ttl"`
+	// RefreshTokenTTL bounds a login session; after it the user has to
+	// log in again however often the session was refreshed.
+	RefreshTokenTTL Duration `json:"refresh_token_ttl" yaml:"refresh_token_ttl"`
+	// BootstrapUser and BootstrapPassword create an initial admin account
+	// at startup if it does not exist yet.
+	BootstrapUser     string `json:"bootstrap_user" yaml:"bootstrap_user"`
+	BootstrapPassword string `json:"bootstrap_password" yaml:"bootstrap_password"`
+}
+
+// OIDC configures staff login through an OpenID Connect provider such as
+// Keycloak or Auth0. It is disabled while IssuerURL is empty.
+type OIDC struct {
+	IssuerURL    string `json:"issuer_url" yaml:"issuer_url"`
+	ClientID     string `json:"client_id" yaml:"client_id"`
+	ClientSecret string `json:"client_secret" yaml:"client_secret"`
+	// RedirectURL is the public URL of GET /auth/oidc/callback.
+	RedirectURL string `json:"redirect_url" yaml:"redirect_url"`
+	GroupsClaim string `json:"groups_claim" yaml:"groups_claim"`
+	// GroupRoles maps provider groups to admin, agent or customer. Users
+	// in none of them cannot log in.
+	GroupRoles map[string]string `json:"group_roles" yaml:"group_roles"`
+}
+
+// RateLimit throttles each client to a token bucket of Burst requests
+// refilled at RequestsPerSecond, one per client address and one per
+// authenticated user. It is off by default: without ClientIPHeader, every
+// client behind a proxy shares the proxy's address.
+type RateLimit struct {
+	// RequestsPerSecond of 0, the default, disables rate limiting.
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int     `json:"burst" yaml:"burst"`
+	// ClientIPHeader, if set, is read for the client address instead of
+	// the connection. Only set it behind a proxy that overwrites it.
+	ClientIPHeader string `json:"client_ip_header" yaml:"client_ip_header"`
+}
+
+// CORS configures cross-origin access for browser applications. It is
+// disabled while AllowedOrigins is empty.
+type CORS struct {
+	// AllowedOrigins lists exact origins, or "*" for any.
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins"`
+	AllowedMethods []string `json:"allowed_methods" yaml:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers" yaml:"allowed_headers"`
+	// MaxAge is how long browsers cache a preflight response.
+	MaxAge Duration `json:"max_age" yaml:"max_age"`
+}
+
+// Cleaning configures the cleaning step a car goes through after each
+// return before it can be rented again.
+type Cleaning struct {
+	// TurnaroundSLA is how long after the return a car should be clean.
+	TurnaroundSLA Duration `json:"turnaround_sla" yaml:"turnaround_sla"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in
+// configuration files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+	return Config{
+		ListenAddr: ":8080",
+		LogLevel:   "info",
+		Database: Database{
+			Driver:       "sqlite",
+			DSN:          "cars.db",
+			MaxIdleConns: 2,
+		},
+		Server: Server{
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(15 * time.Second),
+			IdleTimeout:       Duration(60 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			HandlerTimeout:    Duration(10 * time.Second),
+			MaxBodyBytes:      1 << 20,
+		},
+		Tracing: Tracing{
+			ServiceName: "carrental",
+			SampleRatio: 1,
+		},
+		Auth: Auth{
+			TokenTTL:        Duration(time.Hour),
+			RefreshTokenTTL: Duration(30 * 24 * time.Hour),
+		},
+		OIDC: OIDC{
+			GroupsClaim: "groups",
+		},
+		RateLimit: RateLimit{
+			Burst: 20,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Accept", "If-Match"},
+			MaxAge:         Duration(10 * time.Minute),
+		},
+		Cleaning: Cleaning{
+			TurnaroundSLA: Duration(2 * time.Hour),
+		},
+	}
+}
+
+// SlogLevel returns the parsed log level.
+func (c Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// setting is one configuration value that can be set from the environment
+// or a flag.
+type setting struct {
+	name   string // flag name; the environment variable is derived from it
+	usage  string
+	set    func(c *Config, v string) error
+	isBool bool // the flag may be given without a value
+}
+
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+var settings = []setting{
+	{name: "listen-addr", usage: "address the HTTP server listens on", set: func(c *Config, v string) error {
+		c.ListenAddr = v
+		return nil
+	}},
+	{name: "log-level", usage: "minimum log level: debug, info, warn or error", set: func(c *Config, v string) error {
+		c.LogLevel = v
+		return nil
+	}},
+	{name: "db-driver", usage: "database driver: sqlite, postgres or mysql", set: func(c *Config, v string) error {
+		c.Database.Driver = v
+		return nil
+	}},
+	{name: "db-dsn", usage: "database data source name", set: func(c *Config, v string) error {
+		c.Database.DSN = v
+		return nil
+	}},
+	{name: "db-max-open-conns", usage: "maximum open database connections (0 means unlimited)", set: func(c *Config, v string) error {
+		return setInt(&c.Database.MaxOpenConns, v)
+	}},
+	{name: "db-max-idle-conns", usage: "maximum idle database connections", set: func(c *Config, v string) error {
+		return setInt(&c.Database.MaxIdleConns, v)
+	}},
+	{name: "db-conn-max-lifetime", usage: "maximum lifetime of a database connection (0 means unlimited)", set: func(c *Config, v string) error {
+		return c.Database.ConnMaxLifetime.UnmarshalText([]byte(v))
+	}},
+	{name: "read-header-timeout", usage: "maximum duration for reading request headers", set: func(c *Config, v string) error {
+		return c.Server.ReadHeaderTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "read-timeout", usage: "maximum duration for reading a request", set: func(c *Config, v string) error {
+		return c.Server.ReadTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "write-timeout", usage: "maximum duration for writing a response", set: func(c *Config, v string) error {
+		return c.Server.WriteTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "idle-timeout", usage: "maximum time to keep an idle keep-alive connection open", set: func(c *Config, v string) error {
+		return c.Server.IdleTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "shutdown-timeout", usage: "maximum time to wait for in-flight requests on shutdown", set: func(c *Config, v string) error {
+		return c.Server.ShutdownTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "handler-timeout", usage: "maximum time to serve a request before answering 503 (0 means unlimited)", set: func(c *Config, v string) error {
+		return c.Server.HandlerTimeout.UnmarshalText([]byte(v))
+	}},
+	{name: "max-body-bytes", usage: "maximum size of a request body in bytes", set: func(c *Config, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		c.Server.MaxBodyBytes = n
+		return nil
+	}},
+	{name: "trace-service-name", usage: "service name reported in traces", set: func(c *Config, v string) error {
+		c.Tracing.ServiceName = v
+		return nil
+	}},
+	{name: "otlp-endpoint", usage: "OTLP/HTTP trace collector host:port (empty disables tracing export)", set: func(c *Config, v string) error {
+		c.Tracing.OTLPEndpoint = v
+		return nil
+	}},
+	{name: "otlp-insecure", usage: "send traces over plain HTTP", isBool: true, set: func(c *Config, v string) error {
+		return setBool(&c.Tracing.OTLPInsecure, v)
+	}},
+	{name: "trace-sample-ratio", usage: "fraction of new traces to sample, between 0 and 1", set: func(c *Config, v string) error {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("sample ratio %v is outside [0, 1]", ratio)
+		}
+		c.Tracing.SampleRatio = ratio
+		return nil
+	}},
+	{name: "jwt-signing-key", usage: "HMAC key used to sign access tokens (empty generates one at startup)", set: func(c *Config, v string) error {
+		c.Auth.JWTSigningKey = v
+		return nil
+	}},
+	{name: "token-ttl", usage: "lifetime of issued access tokens", set: func(c *Config, v string) error {
+		return c.Auth.TokenTTL.UnmarshalText([]byte(v))
+	}},
+	{name: "refresh-token-ttl", usage: "lifetime of a login session renewed with refresh tokens", set: func(c *Config, v string) error {
+		return c.Auth.RefreshTokenTTL.UnmarshalText([]byte(v))
+	}},
+	{name: "auth-bootstrap-user", usage: "username of an admin account created at startup if missing", set: func(c *Config, v string) error {
+		c.Auth.BootstrapUser = v
+		return nil
+	}},
+	{name: "auth-bootstrap-password", usage: "password of the bootstrap account", set: func(c *Config, v string) error {
+		c.Auth.BootstrapPassword = v
+		return nil
+	}},
+	{name: "oidc-issuer-url", usage: "OIDC provider issuer URL (empty disables OIDC login)", set: func(c *Config, v string) error {
+		c.OIDC.IssuerURL = v
+		return nil
+	}},
+	{name: "oidc-client-id", usage: "OIDC client ID", set: func(c *Config, v string) error {
+		c.OIDC.ClientID = v
+		return nil
+	}},
+	{name: "oidc-client-secret", usage: "OIDC client secret", set: func(c *Config, v string) error {
+		c.OIDC.ClientSecret = v
+		return nil
+	}},
+	{name: "oidc-redirect-url", usage: "public URL of the OIDC callback route", set: func(c *Config, v string) error {
+		c.OIDC.RedirectURL = v
+		return nil
+	}},
+	{name: "oidc-groups-claim", usage: "ID token claim listing the user's groups", set: func(c *Config, v string) error {
+		c.OIDC.GroupsClaim = v
+		return nil
+	}},
+	{name: "oidc-group-roles", usage: "comma-separated group=role pairs, e.g. fleet-admins=admin,front-desk=agent", set: func(c *Config, v string) error {
+		roles := make(map[string]string)
+		for _, pair := range strings.Split(v, ",") {
+			group, role, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || group == "" || role == "" {
+				return fmt.Errorf("invalid group=role pair %q", pair)
+			}
+			roles[group] = role
+		}
+		c.OIDC.GroupRoles = roles
+		return nil
+	}},
+	{name: "rate-limit-rps", usage: "requests per second allowed per client (0 disables rate limiting)", set: func(c *Config, v string) error {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		c.RateLimit.RequestsPerSecond = rps
+		return nil
+	}},
+	{name: "rate-limit-burst", usage: "requests a client may make at once before being rate limited", set: func(c *Config, v string) error {
+		return setInt(&c.RateLimit.Burst, v)
+	}},
+	{name: "rate-limit-client-ip-header", usage: "header carrying the client address, set by a trusted proxy", set: func(c *Config, v string) error {
+		c.RateLimit.ClientIPHeader = v
+		return nil
+	}},
+	{name: "cors-allowed-origins", usage: "comma-separated origins allowed to call the API from a browser, or *", set: func(c *Config, v string) error {
+		c.CORS.AllowedOrigins = splitList(v)
+		return nil
+	}},
+	{name: "cors-allowed-methods", usage: "comma-separated methods allowed in cross-origin requests", set: func(c *Config, v string) error {
+		c.CORS.AllowedMethods = splitList(v)
+		return nil
+	}},
+	{name: "cors-allowed-headers", usage: "comma-separated request headers allowed in cross-origin requests", set: func(c *Config, v string) error {
+		c.CORS.AllowedHeaders = splitList(v)
+		return nil
+	}},
+	{name: "cors-max-age", usage: "how long browsers may cache a CORS preflight response", set: func(c *Config, v string) error {
+		return c.CORS.MaxAge.UnmarshalText([]byte(v))
+	}},
+	{name: "cleaning-sla", usage: "how long after a return a car should be cleaned and bookable again", set: func(c *Config, v string) error {
+		return c.Cleaning.TurnaroundSLA.UnmarshalText([]byte(v))
+	}},
+}
+
+func setBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+// Load registers the configuration flags on fs, parses args and returns
+// the merged configuration. The file is taken from -config or
+// CARRENTAL_CONFIG; its format is chosen by extension (.yaml, .yml or
+// .json). getenv is usually os.Getenv.
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	configPath := fs.String("config", "", "path to a YAML or JSON configuration file (env "+EnvPrefix+"CONFIG)")
+
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		name := s.name
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env())
+		record := func(v string) error {
+			flagValues[name] = v
+			return nil
+		}
+		if s.isBool {
+			fs.BoolFunc(name, usage, record)
+		} else {
+			fs.Func(name, usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	path := *configPath
+	if path == "" {
+		path = getenv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if v := getenv(s.env()); v != "" {
+			if err := s.set(&cfg, v); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", s.env(), err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flagValues[s.name]; ok {
+			if err := s.set(&cfg, v); err != nil {
+				return Config{}, fmt.Errorf("-%s: %w", s.name, err)
+			}
+		}
+	}
+
+	if _, err := cfg.SlogLevel(); err != nil {
+		return Config{}, fmt.Errorf("log level: %w", err)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return Config{}, fmt.Errorf("tracing sample ratio %v is outside [0, 1]", cfg.Tracing.SampleRatio)
+	}
+	if cfg.Server.HandlerTimeout < 0 {
+		return Config{}, fmt.Errorf("handler timeout %v must not be negative", time.Duration(cfg.Server.HandlerTimeout))
+	}
+	if cfg.Server.MaxBodyBytes <= 0 {
+		return Config{}, fmt.Errorf("max body size %d must be positive", cfg.Server.MaxBodyBytes)
+	}
+	if cfg.Auth.TokenTTL <= 0 {
+		return Config{}, fmt.Errorf("token TTL %v must be positive", time.Duration(cfg.Auth.TokenTTL))
+	}
+	if cfg.Auth.RefreshTokenTTL < cfg.Auth.TokenTTL {
+		return Config{}, fmt.Errorf("refresh token TTL %v must not be shorter than the token TTL %v",
+			time.Duration(cfg.Auth.RefreshTokenTTL), time.Duration(cfg.Auth.TokenTTL))
+	}
+	if (cfg.Auth.BootstrapUser == "") != (cfg.Auth.BootstrapPassword == "") {
+		return Config{}, fmt.Errorf("auth bootstrap user and password must be set together")
+	}
+	if cfg.OIDC.IssuerURL != "" && (cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "") {
+		return Config{}, fmt.Errorf("OIDC login needs a client ID and a redirect URL")
+	}
+	if cfg.RateLimit.RequestsPerSecond < 0 {
+		return Config{}, fmt.Errorf("rate limit %v must not be negative", cfg.RateLimit.RequestsPerSecond)
+	}
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		if origin != "*" && (strings.HasSuffix(origin, "/") || !strings.Contains(origin, "://")) {
+			return Config{}, fmt.Errorf("CORS origin %q must be scheme://host[:port] or *", origin)
+		}
+	}
+	if cfg.RateLimit.RequestsPerSecond > 0 && cfg.RateLimit.Burst < 1 {
+		return Config{}, fmt.Errorf("rate limit burst %d must be at least 1", cfg.RateLimit.Burst)
+	}
+	if cfg.Cleaning.TurnaroundSLA <= 0 {
+		return Config{}, fmt.Errorf("cleaning SLA %v must be positive", time.Duration(cfg.Cleaning.TurnaroundSLA))
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension %q", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
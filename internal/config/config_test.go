@@ -0,0 +1,179 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args []string, env map[string]string) (Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	return Load(fs, args, func(key string) string { return env[key] })
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(t, nil, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("got %+v, want defaults %+v", cfg, Default())
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+listen_addr: ":9000"
+log_level: debug
+database:
+  driver: postgres
+  dsn: postgres://file
+server:
+  read_timeout: 5s
+`)
+	env := map[string]string{
+		"CARRENTAL_CONFIG": path,
+		"CARRENTAL_DB_DSN": "postgres://env",
+	}
+
+	cfg, err := load(t, []string{"-listen-addr", ":9100"}, env)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.ListenAddr != ":9100" {
+		t.Errorf("listen addr = %q, want flag value :9100", cfg.ListenAddr)
+	}
+	if cfg.Database.DSN != "postgres://env" {
+		t.Errorf("dsn = %q, want env value", cfg.Database.DSN)
+	}
+	if cfg.Database.Driver != "postgres" || cfg.LogLevel != "debug" {
+		t.Errorf("driver, log level = %q, %q, want file values", cfg.Database.Driver, cfg.LogLevel)
+	}
+	if got := time.Duration(cfg.Server.ReadTimeout); got != 5*time.Second {
+		t.Errorf("read timeout = %v, want 5s", got)
+	}
+	if got := time.Duration(cfg.Server.WriteTimeout); got != 15*time.Second {
+		t.Errorf("write timeout = %v, want default 15s", got)
+	}
+}
+
+func TestLoadJSONFile(t *testing.T) {
+	path := writeFile(t, "config.json", `{"database": {"max_open_conns": 10, "conn_max_lifetime": "5m"}}`)
+
+	cfg, err := load(t, []string{"-config", path}, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Database.MaxOpenConns != 10 || time.Duration(cfg.Database.ConnMaxLifetime) != 5*time.Minute {
+		t.Fatalf("database = %+v, want 10 conns and 5m lifetime", cfg.Database)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"bad int flag", []string{"-db-max-open-conns", "many"}, nil},
+		{"bad duration env", nil, map[string]string{"CARRENTAL_READ_TIMEOUT": "soon"}},
+		{"bad log level", []string{"-log-level", "loud"}, nil},
+		{"sample ratio out of range", []string{"-trace-sample-ratio", "2"}, nil},
+		{"bad bool env", nil, map[string]string{"CARRENTAL_OTLP_INSECURE": "maybe"}},
+		{"non-positive token TTL", []string{"-token-ttl", "0s"}, nil},
+		{"non-positive body limit", []string{"-max-body-bytes", "0"}, nil},
+		{"bad body limit env", nil, map[string]string{"CARRENTAL_MAX_BODY_BYTES": "1MB"}},
+		{"refresh TTL below token TTL", []string{"-token-ttl", "2h", "-refresh-token-ttl", "1h"}, nil},
+		{"bootstrap user without password", []string{"-auth-bootstrap-user", "admin"}, nil},
+		{"OIDC without client", []string{"-oidc-issuer-url", "https://idp.example.com"}, nil},
+		{"bad group roles", []string{"-oidc-group-roles", "admins"}, nil},
+		{"negative rate limit", []string{"-rate-limit-rps", "-1"}, nil},
+		{"CORS origin with path", []string{"-cors-allowed-origins", "https://dashboard.example.com/"}, nil},
+		{"rate limit without burst", []string{"-rate-limit-rps", "10"}, map[string]string{"CARRENTAL_RATE_LIMIT_BURST": "0"}},
+		{"non-positive cleaning SLA", nil, map[string]string{"CARRENTAL_CLEANING_SLA": "0s"}},
+		{"missing file", []string{"-config", "/does/not/exist.yaml"}, nil},
+		{"unknown extension", []string{"-config", writeFile(t, "config.toml", "")}, nil},
+	}
+	for _, tt := range tests {
+		if _, err := load(t, tt.args, tt.env); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestLoadBoolFlag(t *testing.T) {
+	cfg, err := load(t, []string{"-otlp-insecure", "-otlp-endpoint", "collector:4318"}, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Tracing.OTLPInsecure || cfg.Tracing.OTLPEndpoint != "collector:4318" {
+		t.Fatalf("tracing = %+v, want insecure export to collector:4318", cfg.Tracing)
+	}
+}
+
+func TestLoadOIDCGroupRoles(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+oidc:
+  group_roles:
+    from-file: admin
+`)
+	cfg, err := load(t, []string{"-config", path}, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := map[string]string{"from-file": "admin"}; !reflect.DeepEqual(cfg.OIDC.GroupRoles, want) {
+		t.Fatalf("group roles from file = %v, want %v", cfg.OIDC.GroupRoles, want)
+	}
+
+	env := map[string]string{"CARRENTAL_OIDC_GROUP_ROLES": "fleet-admins=admin, front-desk=agent"}
+	cfg, err = load(t, []string{"-config", path}, env)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := map[string]string{"fleet-admins": "admin", "front-desk": "agent"}; !reflect.DeepEqual(cfg.OIDC.GroupRoles, want) {
+		t.Fatalf("group roles from env = %v, want %v", cfg.OIDC.GroupRoles, want)
+	}
+}
+
+func TestLoadCORSLists(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+cors:
+  allowed_origins: [https://dashboard.example.com]
+`)
+	cfg, err := load(t, []string{"-config", path}, nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := []string{"https://dashboard.example.com"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Fatalf("origins from file = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	if want := []string{"GET", "POST", "PUT", "PATCH", "DELETE"}; !reflect.DeepEqual(cfg.CORS.AllowedMethods, want) {
+		t.Fatalf("default methods = %v, want %v", cfg.CORS.AllowedMethods, want)
+	}
+
+	cfg, err = load(t, []string{"-config", path, "-cors-allowed-methods", "GET, ,POST"}, map[string]string{"CARRENTAL_CORS_ALLOWED_ORIGINS": "http://localhost:3000,https://dashboard.example.com"})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := []string{"http://localhost:3000", "https://dashboard.example.com"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Fatalf("origins from env = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	if want := []string{"GET", "POST"}; !reflect.DeepEqual(cfg.CORS.AllowedMethods, want) {
+		t.Fatalf("methods from flag = %v, want %v", cfg.CORS.AllowedMethods, want)
+	}
+}
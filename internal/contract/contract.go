@@ -0,0 +1,91 @@
+// Package contract verifies the HTTP API against consumer contracts:
+// request and response pairs recorded by a client, in the spirit of Pact.
+// A contract only states what its consumer relies on, so the server may add
+// fields without breaking it, but removing or retyping one does.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Contract is the set of interactions one consumer expects from the API.
+// Interactions are replayed in order, so later ones may depend on the
+// state earlier ones created.
+type Contract struct {
+	Consumer     string        `json:"consumer"`
+	Provider     string        `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response the consumer expects.
+type Interaction struct {
+	Description string   `json:"description"`
+	Request     Request  `json:"request"`
+	Response    Response `json:"response"`
+}
+
+// Request is replayed against the server. Occurrences of RunPlaceholder in
+// Path and Body are replaced with an ID unique to the verification run, so
+// a contract can create resources on a server that already has data.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	// Authenticated requests carry the verifier's bearer token.
+	Authenticated bool `json:"authenticated,omitempty"`
+}
+
+// Response is what the consumer expects back. Headers must match exactly;
+// Body is an example matched by type (see Match). A missing Body is not
+// checked.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// RunPlaceholder is substituted with the verification run ID.
+const RunPlaceholder = "{{run}}"
+
+// Load reads a contract from a JSON file.
+func Load(path string) (Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Contract{}, err
+	}
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Contract{}, fmt.Errorf("contract %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadAll reads the contracts at paths. A directory contributes every
+// *.json file in it, in name order.
+func LoadAll(paths ...string) ([]Contract, error) {
+	var contracts []Contract
+	for _, p := range paths {
+		files := []string{p}
+		if info, err := os.Stat(p); err != nil {
+			return nil, err
+		} else if info.IsDir() {
+			if files, err = filepath.Glob(filepath.Join(p, "*.json")); err != nil {
+				return nil, err
+			}
+			sort.Strings(files)
+		}
+		for _, f := range files {
+			c, err := Load(f)
+			if err != nil {
+				return nil, err
+			}
+			contracts = append(contracts, c)
+		}
+	}
+	return contracts, nil
+}
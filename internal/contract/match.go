@@ -0,0 +1,122 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Match reports whether actual satisfies the example body expected, both
+// JSON documents. Matching is by type rather than by value:
+//
+//   - every key of an expected object must be present in the actual object
+//     with a matching value; extra keys are allowed;
+//   - a non-empty expected array matches an array with at least one
+//     element, each of which matches the first expected element; an empty
+//     expected array matches any array;
+//   - scalars match a value of the same JSON type, and whole numbers only
+//     match whole numbers.
+//
+// The error names the first mismatching location, e.g. $[0].mileage.
+func Match(expected, actual []byte) error {
+	var want, got interface{}
+	if err := decode(expected, &want); err != nil {
+		return fmt.Errorf("expected body: %w", err)
+	}
+	if err := decode(actual, &got); err != nil {
+		return fmt.Errorf("response body is not JSON: %w", err)
+	}
+	return match("$", want, got)
+}
+
+func decode(data []byte, v *interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func match(path string, want, got interface{}) error {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		obj, ok := got.(map[string]interface{})
+		if !ok {
+			return mismatch(path, want, got)
+		}
+		keys := make([]string, 0, len(want))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v, ok := obj[k]
+			if !ok {
+				return fmt.Errorf("%s.%s: missing", path, k)
+			}
+			if err := match(path+"."+k, want[k], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		arr, ok := got.([]interface{})
+		if !ok {
+			return mismatch(path, want, got)
+		}
+		if len(want) == 0 {
+			return nil
+		}
+		if len(arr) == 0 {
+			return fmt.Errorf("%s: empty array, want at least one element", path)
+		}
+		for i, v := range arr {
+			if err := match(fmt.Sprintf("%s[%d]", path, i), want[0], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		n, ok := got.(json.Number)
+		if !ok {
+			return mismatch(path, want, got)
+		}
+		if isWhole(want) && !isWhole(n) {
+			return fmt.Errorf("%s: got %s, want a whole number", path, n)
+		}
+		return nil
+	default:
+		if typeName(want) != typeName(got) {
+			return mismatch(path, want, got)
+		}
+		return nil
+	}
+}
+
+func isWhole(n json.Number) bool {
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f)
+}
+
+func mismatch(path string, want, got interface{}) error {
+	return fmt.Errorf("%s: got %s, want %s", path, typeName(got), typeName(want))
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
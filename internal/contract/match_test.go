@@ -0,0 +1,37 @@
+package contract
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		ok               bool
+	}{
+		{`{"a":"x"}`, `{"a":"y","extra":1}`, true},
+		{`{"a":"x"}`, `{}`, false},
+		{`{"a":"x"}`, `{"a":1}`, false},
+		{`{"n":1}`, `{"n":2}`, true},
+		{`{"n":1}`, `{"n":2.5}`, false},
+		{`{"n":1.5}`, `{"n":2}`, true},
+		{`{"b":true}`, `{"b":null}`, false},
+		{`[{"a":"x"}]`, `[{"a":"y"},{"a":"z"}]`, true},
+		{`[{"a":"x"}]`, `[{"a":"y"},{"b":"z"}]`, false},
+		{`[{"a":"x"}]`, `[]`, false},
+		{`[]`, `[1,"two"]`, true},
+		{`[]`, `{}`, false},
+		{`{"o":{"p":[1]}}`, `{"o":{"p":[3,4]}}`, true},
+	}
+	for _, tt := range tests {
+		err := Match([]byte(tt.expected), []byte(tt.actual))
+		if (err == nil) != tt.ok {
+			t.Errorf("Match(%s, %s) = %v, want ok=%v", tt.expected, tt.actual, err, tt.ok)
+		}
+	}
+}
+
+func TestMatchNamesLocation(t *testing.T) {
+	err := Match([]byte(`[{"mileage":1}]`), []byte(`[{"mileage":1},{"mileage":"far"}]`))
+	if err == nil || err.Error() != "$[1].mileage: got string, want number" {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package contract
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backendGo/internal/api"
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+)
+
+// TestProviderHonoursContracts replays the published consumer contracts
+// against the real API on an in-memory database.
+func TestProviderHonoursContracts(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Each connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	cars, err := store.NewSQLRepository(db, "sqlite")
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+	if err := cars.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	auth := service.NewAuthService(cars, cars, service.AuthConfig{SigningKey: []byte("secret"), TokenTTL: time.Hour, RefreshTTL: 24 * time.Hour})
+	if err := auth.CreateUser(ctx, "verifier", "password", service.RoleAdmin); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	token, err := auth.Login(ctx, "verifier", "password")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	server := httptest.NewServer(api.NewHandler(api.Services{
+		Rentals: service.NewRentalService(cars),
+		Changes: service.NewChangeService(cars),
+		Health:  service.NewHealthService(cars),
+		Auth:    auth,
+	}, api.Config{}).Router())
+	t.Cleanup(server.Close)
+
+	contracts, err := LoadAll("../../contracts")
+	if err != nil {
+		t.Fatalf("load contracts: %v", err)
+	}
+	if len(contracts) == 0 {
+		t.Fatal("no contracts found")
+	}
+	v := &Verifier{BaseURL: server.URL, Token: token.AccessToken, Client: server.Client()}
+	for _, c := range contracts {
+		for _, r := range v.Verify(ctx, c) {
+			if r.Err != nil {
+				t.Errorf("%s: %s: %v", r.Consumer, r.Description, r.Err)
+			}
+		}
+	}
+}
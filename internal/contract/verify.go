@@ -0,0 +1,97 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Verifier replays contracts against a running server.
+type Verifier struct {
+	// BaseURL is the server address, e.g. http://localhost:8080.
+	BaseURL string
+	// Token is sent as a bearer token on authenticated requests.
+	Token string
+	// Client sends the requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Result is the outcome of one interaction.
+type Result struct {
+	Consumer    string
+	Description string
+	// Err is nil if the server honoured the interaction.
+	Err error
+}
+
+// Verify replays every interaction of c in order and reports each outcome.
+// It stops early only if ctx is cancelled.
+func (v *Verifier) Verify(ctx context.Context, c Contract) []Result {
+	run := newRunID()
+
+	results := make([]Result, 0, len(c.Interactions))
+	for _, in := range c.Interactions {
+		err := v.verify(ctx, in, run)
+		results = append(results, Result{Consumer: c.Consumer, Description: in.Description, Err: err})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results
+}
+
+func (v *Verifier) verify(ctx context.Context, in Interaction, run string) error {
+	var body io.Reader
+	if len(in.Request.Body) > 0 {
+		body = strings.NewReader(strings.ReplaceAll(string(in.Request.Body), RunPlaceholder, run))
+	}
+	target := strings.TrimSuffix(v.BaseURL, "/") + strings.ReplaceAll(in.Request.Path, RunPlaceholder, run)
+	req, err := http.NewRequestWithContext(ctx, in.Request.Method, target, body)
+	if err != nil {
+		return err
+	}
+	for k, val := range in.Request.Headers {
+		req.Header.Set(k, val)
+	}
+	if in.Request.Authenticated {
+		req.Header.Set("Authorization", "Bearer "+v.Token)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != in.Response.Status {
+		return fmt.Errorf("status %d, want %d: %s", resp.StatusCode, in.Response.Status, bytes.TrimSpace(got))
+	}
+	for k, want := range in.Response.Headers {
+		if val := resp.Header.Get(k); val != want {
+			return fmt.Errorf("header %s = %q, want %q", k, val, want)
+		}
+	}
+	if len(in.Response.Body) > 0 {
+		if err := Match(in.Response.Body, got); err != nil {
+			return fmt.Errorf("body: %w", err)
+		}
+	}
+	return nil
+}
+
+func newRunID() string {
+	return strings.ToUpper(strconv.FormatInt(time.Now().UnixNano(), 36))
+}
@@ -0,0 +1,128 @@
+// Package metrics defines the Prometheus metrics exported on /metrics.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "carrental"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Duration of storage operations by operation name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	CarsRented = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cars_rented_total",
+		Help:      "Cars successfully rented out.",
+	})
+
+	CarReturns = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "car_returns_total",
+		Help:      "Car returns successfully processed.",
+	})
+
+	// CleaningTurnaround divided by its count gives the average time from
+	// a return until the car was clean and bookable again.
+	CleaningTurnaround = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cleaning_turnaround_seconds",
+		Help:      "Time from a car return until its cleaning task was completed.",
+		Buckets:   []float64{900, 1800, 3600, 2 * 3600, 4 * 3600, 8 * 3600, 24 * 3600},
+	})
+
+	CleaningSLABreaches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cleaning_sla_breaches_total",
+		Help:      "Cleaning tasks completed after the turnaround SLA.",
+	})
+
+	DeprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deprecated_requests_total",
+		Help:      "Requests using a deprecated endpoint or field, by surface.",
+	}, []string{"surface"})
+)
+
+// ObserveQuery records the duration of a storage operation started at
+// start. Use it as defer metrics.ObserveQuery("op", time.Now()).
+func ObserveQuery(operation string, start time.Time) {
+	DBQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// scrapeTimeout bounds the database queries behind the counting gauges.
+const scrapeTimeout = 5 * time.Second
+
+// RegisterActiveRentals exports the number of rented cars, read from count
+// on every scrape so it stays correct across restarts and instances.
+func RegisterActiveRentals(count func(ctx context.Context) (int, error)) {
+	registerCount(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rentals_active",
+		Help:      "Cars currently rented out.",
+	}, "active rentals", count)
+}
+
+// RegisterKeyAlerts exports the number of car keys that are missing or were
+// not checked back in, for alerting rules to page on.
+func RegisterKeyAlerts(count func(ctx context.Context) (int, error)) {
+	registerCount(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "key_alerts",
+		Help:      "Car keys that are missing or were not checked back in.",
+	}, "key alerts", count)
+}
+
+// RegisterOverdueCleaning exports the number of open cleaning tasks past
+// the turnaround SLA.
+func RegisterOverdueCleaning(count func(ctx context.Context) (int, error)) {
+	registerCount(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cleaning_tasks_overdue",
+		Help:      "Open cleaning tasks past the turnaround SLA.",
+	}, "overdue cleaning tasks", count)
+}
+
+// registerCount registers a gauge read from count on every scrape.
+func registerCount(opts prometheus.GaugeOpts, what string, count func(ctx context.Context) (int, error)) {
+	promauto.NewGaugeFunc(opts, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+		defer cancel()
+
+		n, err := count(ctx)
+		if err != nil {
+			slog.Error("Error counting "+what, "error", err)
+			return 0
+		}
+		return float64(n)
+	})
+}
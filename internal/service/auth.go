@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backendGo/internal/store"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenIssuer = "carrental"
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserDisabled       = errors.New("user disabled")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrUserExists         = store.ErrUserExists
+	ErrUserNotFound       = store.ErrUserNotFound
+	ErrSessionNotFound    = store.ErrSessionNotFound
+)
+
+// Role decides which routes a principal may use.
+type Role string
+
+const (
+	// RoleAdmin manages the fleet and user accounts.
+	RoleAdmin Role = "admin"
+	// RoleAgent hands cars out and takes them back.
+	RoleAgent Role = "agent"
+	// RoleCustomer can only look at availability.
+	RoleCustomer Role = "customer"
+)
+
+// ParseRole returns the role named s or ErrInvalidRole.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case RoleAdmin, RoleAgent, RoleCustomer:
+		return r, nil
+	}
+	return "", fmt.Errorf("%w %q: want admin, agent or customer", ErrInvalidRole, s)
+}
+
+// Token is an issued access token and the refresh token that renews it.
+type Token struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	// RefreshToken can be exchanged once for a new Token until
+	// RefreshExpiresAt.
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Username string
+	Role     Role
+	// SessionID identifies the login the access token belongs to.
+	SessionID string
+}
+
+// Session is a login as shown to its user and administrators.
+type Session struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	Role       Role      `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// User is an account as shown to administrators.
+type User struct {
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	Disabled  bool      `json:"disabled"`
+}
+
+// AuthService issues and validates access tokens. Every login starts a
+// server-side session; access tokens are only accepted while their session
+// is active, so revoking a session locks its holder out immediately.
+type AuthService interface {
+	// Login checks the credentials and starts a session. It returns
+	// ErrUserDisabled for a disabled user with the right password.
+	Login(ctx context.Context, username, password string) (Token, error)
+	// Issue starts a session for a principal authenticated elsewhere, such
+	// as by an OIDC provider, unless their account is disabled.
+	Issue(ctx context.Context, p Principal) (Token, error)
+	// Refresh exchanges a refresh token for a new token pair. Each refresh
+	// token works once: presenting one that has already been rotated
+	// revokes the session, since either its holder or a thief is replaying
+	// it.
+	Refresh(ctx context.Context, refreshToken string) (Token, error)
+	// Authenticate validates an access token and returns its principal.
+	Authenticate(ctx context.Context, accessToken string) (Principal, error)
+	// Logout revokes the session of an access token.
+	Logout(ctx context.Context, sessionID string) error
+	// Sessions returns the active sessions of a user, newest first.
+	Sessions(ctx context.Context, username string) ([]Session, error)
+	// RevokeSession revokes a session of actor, or of anyone if actor is
+	// an admin. Other users' sessions are reported as ErrSessionNotFound.
+	RevokeSession(ctx context.Context, actor Principal, id string) error
+	// RevokeUserSessions revokes every session of a user, signing them out
+	// everywhere, and returns how many were active. They may log in again.
+	RevokeUserSessions(ctx context.Context, username string) (int64, error)
+	// DisableUser stops a user from logging in, by password or OIDC, and
+	// revokes every session of theirs, as when they leave. It returns how
+	// many sessions were active, or ErrUserNotFound for a name that has
+	// neither an account nor a session.
+	DisableUser(ctx context.Context, username string) (int64, error)
+	// EnableUser lets a disabled user log in again, or returns
+	// ErrUserNotFound.
+	EnableUser(ctx context.Context, username string) error
+	// CreateUser adds a user with the given password and role. An empty
+	// username or password is a *ValidationError.
+	CreateUser(ctx context.Context, username, password string, role Role) error
+	// ListUsers returns every account.
+	ListUsers(ctx context.Context) ([]User, error)
+	// SetRole changes the role of an account. Access tokens issued before
+	// the change keep the old role until they are refreshed.
+	SetRole(ctx context.Context, username string, role Role) error
+}
+
+// claims are the JWT claims of an access token.
+type claims struct {
+	jwt.RegisteredClaims
+	Role      Role   `json:"role"`
+	SessionID string `json:"sid"`
+}
+
+// AuthConfig configures token issuance.
+type AuthConfig struct {
+	// SigningKey signs and verifies tokens with HMAC-SHA256.
+	SigningKey []byte
+	// TokenTTL is how long an access token stays valid.
+	TokenTTL time.Duration
+	// RefreshTTL is how long a session lasts. Refreshing does not extend
+	// it; the user logs in again afterwards.
+	RefreshTTL time.Duration
+}
+
+type authService struct {
+	users    store.UserRepository
+	sessions store.SessionRepository
+	config   AuthConfig
+	now      func() time.Time
+}
+
+// NewAuthService returns an AuthService storing accounts in users and
+// logins in sessions.
+func NewAuthService(users store.UserRepository, sessions store.SessionRepository, config AuthConfig) AuthService {
+	return &authService{users: users, sessions: sessions, config: config, now: time.Now}
+}
+
+// dummyHash is compared against when the user does not exist, so a login
+// for an unknown name takes as long as one with a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+func (s *authService) Login(ctx context.Context, username, password string) (Token, error) {
+	user, err := s.users.GetUser(ctx, username)
+	if errors.Is(err, store.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return Token{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return Token{}, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return Token{}, ErrUserDisabled
+	}
+	return s.issue(ctx, Principal{Username: user.Username, Role: Role(user.Role)})
+}
+
+func (s *authService) Issue(ctx context.Context, p Principal) (Token, error) {
+	user, err := s.users.GetUser(ctx, p.Username)
+	switch {
+	case err == nil && user.Disabled:
+		return Token{}, ErrUserDisabled
+	case err != nil && !errors.Is(err, store.ErrUserNotFound):
+		return Token{}, err
+	}
+	return s.issue(ctx, p)
+}
+
+// issue starts a session for p without checking that the user is enabled.
+func (s *authService) issue(ctx context.Context, p Principal) (Token, error) {
+	id, err := randomToken()
+	if err != nil {
+		return Token{}, err
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return Token{}, err
+	}
+	now := s.now().UTC()
+	session := store.Session{
+		ID:               id,
+		Username:         p.Username,
+		Role:             string(p.Role),
+		RefreshTokenHash: hashSecret(secret),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(s.config.RefreshTTL),
+	}
+	if err := s.sessions.CreateSession(ctx, session); err != nil {
+		return Token{}, err
+	}
+	p.SessionID = id
+	return s.sign(p, id+"."+secret, session.ExpiresAt)
+}
+
+// sign returns a token pair with a new access token for p.
+func (s *authService) sign(p Principal, refreshToken string, refreshExpiresAt time.Time) (Token, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.TokenTTL)
+	c := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   p.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Role:      p.Role,
+		SessionID: p.SessionID,
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(s.config.SigningKey)
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{
+		AccessToken:      signed,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return Token{}, fmt.Errorf("%w: malformed refresh token", ErrInvalidToken)
+	}
+	session, err := s.activeSession(ctx, id)
+	if err != nil {
+		return Token{}, err
+	}
+	hash := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(session.RefreshTokenHash)) != 1 {
+		if err := s.sessions.RevokeSession(ctx, id, s.now().UTC()); err != nil && !errors.Is(err, store.ErrSessionNotFound) {
+			return Token{}, err
+		}
+		return Token{}, fmt.Errorf("%w: refresh token reused, session %s revoked", ErrInvalidToken, id)
+	}
+
+	// Pick up role changes made since the last refresh. Accounts that
+	// only exist at an OIDC provider keep the role they logged in with.
+	role := Role(session.Role)
+	user, err := s.users.GetUser(ctx, session.Username)
+	switch {
+	case err == nil && user.Disabled:
+		return Token{}, fmt.Errorf("%w: user %s disabled", ErrInvalidToken, user.Username)
+	case err == nil:
+		role = Role(user.Role)
+	case !errors.Is(err, store.ErrUserNotFound):
+		return Token{}, err
+	}
+
+	newSecret, err := randomToken()
+	if err != nil {
+		return Token{}, err
+	}
+	err = s.sessions.RotateRefreshToken(ctx, id, hash, hashSecret(newSecret), string(role), s.now().UTC())
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return Token{}, fmt.Errorf("%w: refresh token already used", ErrInvalidToken)
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	return s.sign(Principal{Username: session.Username, Role: role, SessionID: id}, id+"."+newSecret, session.ExpiresAt)
+}
+
+func (s *authService) Authenticate(ctx context.Context, accessToken string) (Principal, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(accessToken, &c, func(*jwt.Token) (interface{}, error) {
+		return s.config.SigningKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	role, err := ParseRole(string(c.Role))
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if _, err := s.activeSession(ctx, c.SessionID); err != nil {
+		return Principal{}, err
+	}
+	return Principal{Username: c.Subject, Role: role, SessionID: c.SessionID}, nil
+}
+
+// activeSession returns the session with the given ID, or ErrInvalidToken
+// if it does not exist, has been revoked or has expired.
+func (s *authService) activeSession(ctx context.Context, id string) (store.Session, error) {
+	session, err := s.sessions.GetSession(ctx, id)
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return store.Session{}, fmt.Errorf("%w: unknown session", ErrInvalidToken)
+	}
+	if err != nil {
+		return store.Session{}, err
+	}
+	if session.RevokedAt != nil {
+		return store.Session{}, fmt.Errorf("%w: session revoked", ErrInvalidToken)
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		return store.Session{}, fmt.Errorf("%w: session expired", ErrInvalidToken)
+	}
+	return session, nil
+}
+
+func (s *authService) Logout(ctx context.Context, sessionID string) error {
+	return s.sessions.RevokeSession(ctx, sessionID, s.now().UTC())
+}
+
+func (s *authService) Sessions(ctx context.Context, username string) ([]Session, error) {
+	sessions, err := s.sessions.ListSessions(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	out := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if !now.Before(session.ExpiresAt) {
+			continue
+		}
+		out = append(out, Session{
+			ID:         session.ID,
+			Username:   session.Username,
+			Role:       Role(session.Role),
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	return out, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, actor Principal, id string) error {
+	session, err := s.sessions.GetSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.Username != actor.Username && actor.Role != RoleAdmin {
+		return ErrSessionNotFound
+	}
+	return s.sessions.RevokeSession(ctx, id, s.now().UTC())
+}
+
+func (s *authService) RevokeUserSessions(ctx context.Context, username string) (int64, error) {
+	return s.sessions.RevokeUserSessions(ctx, username, s.now().UTC())
+}
+
+func (s *authService) DisableUser(ctx context.Context, username string) (int64, error) {
+	return s.users.DisableUser(ctx, username, s.now().UTC())
+}
+
+func (s *authService) EnableUser(ctx context.Context, username string) error {
+	return s.users.EnableUser(ctx, username)
+}
+
+// randomToken returns 32 random bytes, base64url encoded. The encoding has
+// no dots, so a refresh token splits unambiguously into ID and secret.
+func randomToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// hashSecret returns the stored form of a refresh token secret. The secret
+// is random, so a fast hash is enough.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) CreateUser(ctx context.Context, username, password string, role Role) error {
+	var fields []FieldError
+	if username == "" {
+		fields = append(fields, FieldError{Field: "username", Message: "must not be empty"})
+	}
+	if password == "" {
+		fields = append(fields, FieldError{Field: "password", Message: "must not be empty"})
+	}
+	if err := validationError(fields); err != nil {
+		return err
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.users.CreateUser(ctx, store.User{
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         string(role),
+		CreatedAt:    s.now().UTC(),
+	})
+}
+
+func (s *authService) ListUsers(ctx context.Context) ([]User, error) {
+	users, err := s.users.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]User, len(users))
+	for i, u := range users {
+		out[i] = User{Username: u.Username, Role: Role(u.Role), CreatedAt: u.CreatedAt, Disabled: u.Disabled}
+	}
+	return out, nil
+}
+
+func (s *authService) SetRole(ctx context.Context, username string, role Role) error {
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+	return s.users.SetUserRole(ctx, username, string(role))
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// memoryUsers is an in-memory store.UserRepository. Disabling a user
+// revokes their sessions in sessions.
+type memoryUsers struct {
+	accounts map[string]store.User
+	sessions memorySessions
+}
+
+func (m memoryUsers) GetUser(_ context.Context, username string) (store.User, error) {
+	u, ok := m.accounts[username]
+	if !ok {
+		return store.User{}, store.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (m memoryUsers) CreateUser(_ context.Context, u store.User) error {
+	if _, ok := m.accounts[u.Username]; ok {
+		return store.ErrUserExists
+	}
+	m.accounts[u.Username] = u
+	return nil
+}
+
+func (m memoryUsers) ListUsers(context.Context) ([]store.User, error) {
+	users := make([]store.User, 0, len(m.accounts))
+	for _, u := range m.accounts {
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (m memoryUsers) SetUserRole(_ context.Context, username, role string) error {
+	u, ok := m.accounts[username]
+	if !ok {
+		return store.ErrUserNotFound
+	}
+	u.Role = role
+	m.accounts[username] = u
+	return nil
+}
+
+func (m memoryUsers) DisableUser(ctx context.Context, username string, at time.Time) (int64, error) {
+	u, ok := m.accounts[username]
+	if !ok {
+		if !m.hasSession(username) {
+			return 0, store.ErrUserNotFound
+		}
+		u = store.User{Username: username, Role: string(RoleCustomer), CreatedAt: at}
+	}
+	u.Disabled = true
+	m.accounts[username] = u
+	return m.sessions.RevokeUserSessions(ctx, username, at)
+}
+
+func (m memoryUsers) hasSession(username string) bool {
+	for _, s := range m.sessions {
+		if s.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (m memoryUsers) EnableUser(_ context.Context, username string) error {
+	u, ok := m.accounts[username]
+	switch {
+	case !ok:
+		return store.ErrUserNotFound
+	case u.PasswordHash == "":
+		delete(m.accounts, username)
+	default:
+		u.Disabled = false
+		m.accounts[username] = u
+	}
+	return nil
+}
+
+// memorySessions is an in-memory store.SessionRepository.
+type memorySessions map[string]store.Session
+
+func (m memorySessions) CreateSession(_ context.Context, s store.Session) error {
+	m[s.ID] = s
+	return nil
+}
+
+func (m memorySessions) GetSession(_ context.Context, id string) (store.Session, error) {
+	s, ok := m[id]
+	if !ok {
+		return store.Session{}, store.ErrSessionNotFound
+	}
+	return s, nil
+}
+
+func (m memorySessions) RotateRefreshToken(_ context.Context, id, oldHash, newHash, role string, at time.Time) error {
+	s, ok := m[id]
+	if !ok || s.RevokedAt != nil || s.RefreshTokenHash != oldHash {
+		return store.ErrSessionNotFound
+	}
+	s.RefreshTokenHash, s.Role, s.LastUsedAt = newHash, role, at
+	m[id] = s
+	return nil
+}
+
+func (m memorySessions) ListSessions(_ context.Context, username string) ([]store.Session, error) {
+	var sessions []store.Session
+	for _, s := range m {
+		if s.Username == username && s.RevokedAt == nil {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+func (m memorySessions) RevokeSession(_ context.Context, id string, at time.Time) error {
+	s, ok := m[id]
+	if !ok || s.RevokedAt != nil {
+		return store.ErrSessionNotFound
+	}
+	s.RevokedAt = &at
+	m[id] = s
+	return nil
+}
+
+func (m memorySessions) RevokeUserSessions(ctx context.Context, username string, at time.Time) (int64, error) {
+	var n int64
+	for id, s := range m {
+		if s.Username == username && s.RevokedAt == nil {
+			m.RevokeSession(ctx, id, at)
+			n++
+		}
+	}
+	return n, nil
+}
+
+var testAuthConfig = AuthConfig{SigningKey: []byte("secret"), TokenTTL: time.Hour, RefreshTTL: 24 * time.Hour}
+
+func newTestAuth(t *testing.T) *authService {
+	t.Helper()
+
+	sessions := memorySessions{}
+	users := memoryUsers{accounts: map[string]store.User{}, sessions: sessions}
+	auth := NewAuthService(users, sessions, testAuthConfig).(*authService)
+	if err := auth.CreateUser(context.Background(), "alice", "correct horse", RoleAgent); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return auth
+}
+
+func TestLoginAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	p, err := auth.Authenticate(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if p.Username != "alice" || p.Role != RoleAgent {
+		t.Fatalf("principal = %+v, want alice as agent", p)
+	}
+}
+
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+
+	if _, err := auth.Login(ctx, "alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, err := auth.Login(ctx, "bob", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user: got %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestAuthenticateRejectsBadTokens(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	other := NewAuthService(memoryUsers{}, auth.sessions, AuthConfig{SigningKey: []byte("other"), TokenTTL: time.Hour})
+	if _, err := other.Authenticate(ctx, token.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("foreign key: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := auth.Authenticate(ctx, "not-a-jwt"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("garbage: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	auth.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := auth.Authenticate(ctx, token.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+
+	if err := auth.SetRole(ctx, "alice", "owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("unknown role: got %v, want %v", err, ErrInvalidRole)
+	}
+	if err := auth.SetRole(ctx, "bob", RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("unknown user: got %v, want %v", err, ErrUserNotFound)
+	}
+	if err := auth.SetRole(ctx, "alice", RoleAdmin); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	p, err := auth.Authenticate(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if p.Role != RoleAdmin {
+		t.Fatalf("role = %q, want %q", p.Role, RoleAdmin)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	first, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if first.RefreshToken == "" || !first.RefreshExpiresAt.After(first.ExpiresAt) {
+		t.Fatalf("token = %+v, want a refresh token outliving the access token", first)
+	}
+
+	if err := auth.SetRole(ctx, "alice", RoleAdmin); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	second, err := auth.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || !second.RefreshExpiresAt.Equal(first.RefreshExpiresAt) {
+		t.Fatalf("refreshed = %+v, want a new refresh token for the same session", second)
+	}
+	p, err := auth.Authenticate(ctx, second.AccessToken)
+	if err != nil {
+		t.Fatalf("authenticate refreshed token: %v", err)
+	}
+	if p.Role != RoleAdmin {
+		t.Fatalf("role after refresh = %q, want %q", p.Role, RoleAdmin)
+	}
+
+	// Replaying the rotated token revokes the session for everyone.
+	if _, err := auth.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reuse: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := auth.Authenticate(ctx, second.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("access token after reuse: got %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := auth.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("current refresh token after reuse: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	for _, refresh := range []string{"", "no-dot", "unknown.secret"} {
+		if _, err := auth.Refresh(ctx, refresh); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("refresh %q: got %v, want %v", refresh, err, ErrInvalidToken)
+		}
+	}
+
+	auth.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	if _, err := auth.Refresh(ctx, token.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired session: got %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestLogoutAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	if err := auth.CreateUser(ctx, "bob", "battery staple", RoleCustomer); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	login := func(username, password string) (Token, Principal) {
+		t.Helper()
+		token, err := auth.Login(ctx, username, password)
+		if err != nil {
+			t.Fatalf("login %s: %v", username, err)
+		}
+		p, err := auth.Authenticate(ctx, token.AccessToken)
+		if err != nil {
+			t.Fatalf("authenticate %s: %v", username, err)
+		}
+		return token, p
+	}
+
+	token, alice := login("alice", "correct horse")
+	if err := auth.Logout(ctx, alice.SessionID); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if _, err := auth.Authenticate(ctx, token.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("after logout: got %v, want %v", err, ErrInvalidToken)
+	}
+
+	_, alice = login("alice", "correct horse")
+	_, bob := login("bob", "battery staple")
+	if err := auth.RevokeSession(ctx, bob, alice.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoke someone else's session: got %v, want %v", err, ErrSessionNotFound)
+	}
+	sessions, err := auth.Sessions(ctx, "alice")
+	if err != nil || len(sessions) != 1 || sessions[0].ID != alice.SessionID {
+		t.Fatalf("sessions = %+v, %v, want only %s", sessions, err, alice.SessionID)
+	}
+	if err := auth.RevokeSession(ctx, Principal{Username: "root", Role: RoleAdmin}, alice.SessionID); err != nil {
+		t.Fatalf("admin revoke: %v", err)
+	}
+
+	login("bob", "battery staple")
+	n, err := auth.RevokeUserSessions(ctx, "bob")
+	if err != nil || n != 2 {
+		t.Fatalf("revoke bob's sessions = %d, %v, want 2", n, err)
+	}
+	if sessions, err := auth.Sessions(ctx, "bob"); err != nil || len(sessions) != 0 {
+		t.Fatalf("bob's sessions = %+v, %v, want none", sessions, err)
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	oidc, err := auth.Issue(ctx, Principal{Username: "carol", Role: RoleAgent})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	for _, name := range []string{"alice", "carol"} {
+		if n, err := auth.DisableUser(ctx, name); err != nil || n != 1 {
+			t.Fatalf("disable %s = %d, %v, want 1 session revoked", name, n, err)
+		}
+		if sessions, err := auth.Sessions(ctx, name); err != nil || len(sessions) != 0 {
+			t.Fatalf("%s's sessions = %+v, %v, want none", name, sessions, err)
+		}
+	}
+	if _, err := auth.Login(ctx, "alice", "correct horse"); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("login: got %v, want %v", err, ErrUserDisabled)
+	}
+	if _, err := auth.Login(ctx, "alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("login with a wrong password: got %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, err := auth.Issue(ctx, Principal{Username: "carol", Role: RoleAgent}); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("OIDC login: got %v, want %v", err, ErrUserDisabled)
+	}
+	for _, refresh := range []string{token.RefreshToken, oidc.RefreshToken} {
+		if _, err := auth.Refresh(ctx, refresh); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("refresh: got %v, want %v", err, ErrInvalidToken)
+		}
+	}
+
+	users, err := auth.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	for _, u := range users {
+		if !u.Disabled {
+			t.Errorf("user %s is not disabled", u.Username)
+		}
+	}
+	if _, err := auth.DisableUser(ctx, "nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("disable unknown user: got %v, want %v", err, ErrUserNotFound)
+	}
+
+	for _, name := range []string{"alice", "carol"} {
+		if err := auth.EnableUser(ctx, name); err != nil {
+			t.Fatalf("enable %s: %v", name, err)
+		}
+	}
+	if _, err := auth.Login(ctx, "alice", "correct horse"); err != nil {
+		t.Fatalf("login after enabling: %v", err)
+	}
+	if _, err := auth.Issue(ctx, Principal{Username: "carol", Role: RoleAgent}); err != nil {
+		t.Fatalf("OIDC login after enabling: %v", err)
+	}
+}
+
+func TestRefreshRejectsDisabledUser(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+	token, err := auth.Login(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	// A session that survived disabling, as one created concurrently might.
+	users := auth.users.(memoryUsers)
+	alice := users.accounts["alice"]
+	alice.Disabled = true
+	users.accounts["alice"] = alice
+	if _, err := auth.Refresh(ctx, token.RefreshToken); !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("refresh: got %v, want %v for a disabled user", err, ErrInvalidToken)
+	}
+}
+
+func TestAuthenticateRejectsTokenWithoutSession(t *testing.T) {
+	ctx := context.Background()
+	auth := newTestAuth(t)
+
+	token, err := auth.sign(Principal{Username: "alice", Role: RoleAgent}, "", time.Time{})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	_, err = auth.Authenticate(ctx, token.AccessToken)
+	if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), "unknown session") {
+		t.Fatalf("got %v, want %v for an unknown session", err, ErrInvalidToken)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"backendGo/internal/store"
+)
+
+// Errors returned by CategoryService, besides *ValidationError.
+var (
+	ErrCategoryNotFound = store.ErrCategoryNotFound
+	ErrCategoryExists   = store.ErrCategoryExists
+	ErrCategoryInUse    = store.ErrCategoryInUse
+)
+
+// categoryNamePattern matches category names: lowercase letters and digits,
+// optionally with inner hyphens, at most 16 characters to fit the category
+// column of cars on MySQL. They appear in URL paths.
+var categoryNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,14}[a-z0-9])?$`)
+
+// maxCategoryDescription is the column size on MySQL.
+const maxCategoryDescription = 255
+
+// CategoryService manages the classes of cars that customers book.
+type CategoryService interface {
+	// Categories returns every category ordered by name.
+	Categories(ctx context.Context) ([]store.Category, error)
+	// AddCategory validates c and adds it.
+	AddCategory(ctx context.Context, c store.Category) error
+	// UpdateCategory validates c and sets the description of the category
+	// with its name, returning the updated category.
+	UpdateCategory(ctx context.Context, c store.Category) (store.Category, error)
+	// DeleteCategory removes a category no car belongs to.
+	DeleteCategory(ctx context.Context, name string) error
+	// Availability returns how many cars of each category can be rented
+	// now.
+	Availability(ctx context.Context) ([]store.CategoryAvailability, error)
+}
+
+type categoryService struct {
+	categories store.CategoryRepository
+}
+
+// NewCategoryService returns a CategoryService backed by categories.
+func NewCategoryService(categories store.CategoryRepository) CategoryService {
+	return &categoryService{categories: categories}
+}
+
+func (s *categoryService) Categories(ctx context.Context) ([]store.Category, error) {
+	return s.categories.ListCategories(ctx)
+}
+
+func (s *categoryService) AddCategory(ctx context.Context, c store.Category) error {
+	fields := descriptionErrors(&c)
+	if !categoryNamePattern.MatchString(c.Name) {
+		fields = append(fields, FieldError{Field: "name", Message: "must be 1 to 16 lowercase letters or digits, optionally with inner hyphens"})
+	}
+	if err := validationError(fields); err != nil {
+		return err
+	}
+	return s.categories.AddCategory(ctx, c)
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, c store.Category) (store.Category, error) {
+	if err := validationError(descriptionErrors(&c)); err != nil {
+		return store.Category{}, err
+	}
+	if err := s.categories.UpdateCategory(ctx, c); err != nil {
+		return store.Category{}, err
+	}
+	return s.categories.GetCategory(ctx, c.Name)
+}
+
+func (s *categoryService) DeleteCategory(ctx context.Context, name string) error {
+	return s.categories.DeleteCategory(ctx, name)
+}
+
+func (s *categoryService) Availability(ctx context.Context) ([]store.CategoryAvailability, error) {
+	return s.categories.CategoryAvailability(ctx)
+}
+
+// descriptionErrors trims the description of c and checks it.
+func descriptionErrors(c *store.Category) []FieldError {
+	c.Description = strings.TrimSpace(c.Description)
+	switch {
+	case c.Description == "":
+		return []FieldError{{Field: "description", Message: "must not be empty"}}
+	case len(c.Description) > maxCategoryDescription:
+		return []FieldError{{Field: "description", Message: fmt.Sprintf("must be at most %d bytes", maxCategoryDescription)}}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"backendGo/internal/store"
+)
+
+// memoryCategories is a store.CategoryRepository that records the last
+// category added.
+type memoryCategories struct {
+	store.CategoryRepository
+	added store.Category
+}
+
+func (m *memoryCategories) AddCategory(_ context.Context, c store.Category) error {
+	m.added = c
+	return nil
+}
+
+func TestAddCategoryValidation(t *testing.T) {
+	tests := []struct {
+		c    store.Category
+		want string
+	}{
+		{store.Category{Name: "people-carrier", Description: "  Seven seats  "}, ""},
+		{store.Category{Name: "x", Description: "One letter"}, ""},
+		{store.Category{Name: "SUV", Description: "Upper case"}, "name"},
+		{store.Category{Name: "-van", Description: "Leading hyphen"}, "name"},
+		{store.Category{Name: "seventeen-letters", Description: "Too long"}, "name"},
+		{store.Category{Name: "van", Description: " "}, "description"},
+		{store.Category{Name: "", Description: strings.Repeat("x", 256)}, "description,name"},
+	}
+	for _, tt := range tests {
+		repo := &memoryCategories{}
+		err := NewCategoryService(repo).AddCategory(context.Background(), tt.c)
+		var got []string
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			for _, f := range invalid.Fields {
+				got = append(got, f.Field)
+			}
+		} else if err != nil {
+			t.Fatalf("AddCategory(%+v) = %v, want a *ValidationError", tt.c, err)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("AddCategory(%+v) rejects %v, want %s", tt.c, got, tt.want)
+		}
+		if tt.want == "" && repo.added.Description != strings.TrimSpace(tt.c.Description) {
+			t.Errorf("added %+v, want the description trimmed", repo.added)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// MaxChangesPerPage caps the number of changes returned by one Since call.
+const MaxChangesPerPage = 1000
+
+// changePollInterval is how often WaitFor checks the change log.
+const changePollInterval = time.Second
+
+// ChangeService serves the change log to downstream consumers such as the
+// data warehouse sync.
+type ChangeService interface {
+	// Since returns up to limit changes recorded after cursor, oldest
+	// first. Cursor 0 starts from the beginning of the log.
+	Since(ctx context.Context, cursor int64, limit int) ([]store.Change, error)
+	// Latest returns the cursor of the newest change to an entity of the
+	// given type, or 0 if there is none.
+	Latest(ctx context.Context, entityType string) (int64, error)
+	// WaitFor blocks until a change to an entity of the given type is
+	// recorded after cursor and returns the newest cursor. It reads the
+	// change log rather than listening in process, so writes through other
+	// instances of the server wake it too. It returns ctx.Err() if ctx is
+	// done first.
+	WaitFor(ctx context.Context, entityType string, cursor int64) (int64, error)
+}
+
+type changeService struct {
+	changes      store.ChangeRepository
+	pollInterval time.Duration
+}
+
+// NewChangeService returns a ChangeService backed by changes.
+func NewChangeService(changes store.ChangeRepository) ChangeService {
+	return &changeService{changes: changes, pollInterval: changePollInterval}
+}
+
+func (s *changeService) Since(ctx context.Context, cursor int64, limit int) ([]store.Change, error) {
+	if limit <= 0 || limit > MaxChangesPerPage {
+		limit = MaxChangesPerPage
+	}
+	return s.changes.ListChanges(ctx, cursor, limit)
+}
+
+func (s *changeService) Latest(ctx context.Context, entityType string) (int64, error) {
+	return s.changes.LatestChangeID(ctx, entityType)
+}
+
+func (s *changeService) WaitFor(ctx context.Context, entityType string, cursor int64) (int64, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		latest, err := s.changes.LatestChangeID(ctx, entityType)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return cursor, ctxErr
+			}
+			return cursor, err
+		}
+		if latest > cursor {
+			return latest, nil
+		}
+		select {
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
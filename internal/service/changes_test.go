@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// memoryChanges is an in-memory store.ChangeRepository holding the latest
+// change ID per entity type.
+type memoryChanges struct {
+	mu     sync.Mutex
+	latest map[string]int64
+}
+
+func (m *memoryChanges) ListChanges(context.Context, int64, int) ([]store.Change, error) {
+	return nil, nil
+}
+
+func (m *memoryChanges) LatestChangeID(_ context.Context, entityType string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latest[entityType], nil
+}
+
+func (m *memoryChanges) record(entityType string, id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latest[entityType] = id
+}
+
+func TestWaitForReturnsOnChange(t *testing.T) {
+	changes := &memoryChanges{latest: map[string]int64{store.EntityCar: 3}}
+	svc := &changeService{changes: changes, pollInterval: 5 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A cursor behind the log returns at once.
+	if got, err := svc.WaitFor(ctx, store.EntityCar, 1); err != nil || got != 3 {
+		t.Fatalf("behind: got %d, %v, want 3", got, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		changes.record("driver", 4)
+		time.Sleep(20 * time.Millisecond)
+		changes.record(store.EntityCar, 5)
+	}()
+	if got, err := svc.WaitFor(ctx, store.EntityCar, 3); err != nil || got != 5 {
+		t.Fatalf("wait: got %d, %v, want 5", got, err)
+	}
+}
+
+func TestWaitForTimesOut(t *testing.T) {
+	changes := &memoryChanges{latest: map[string]int64{store.EntityCar: 3}}
+	svc := &changeService{changes: changes, pollInterval: 5 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	got, err := svc.WaitFor(ctx, store.EntityCar, 3)
+	if !errors.Is(err, context.DeadlineExceeded) || got != 3 {
+		t.Fatalf("got %d, %v, want 3 and %v", got, err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"backendGo/internal/metrics"
+	"backendGo/internal/store"
+)
+
+// Errors returned by CleaningService, besides *ValidationError.
+var (
+	ErrCleaningTaskNotFound = store.ErrCleaningTaskNotFound
+	ErrCleaningTaskDone     = store.ErrCleaningTaskDone
+)
+
+// CleaningTask is a store.CleaningTask with its turnaround deadline.
+type CleaningTask struct {
+	store.CleaningTask
+	DueAt time.Time `json:"due_at"`
+	// Overdue reports whether the task was, or is still, open after DueAt.
+	Overdue bool `json:"overdue"`
+}
+
+// CleaningService runs the cleaning step between a return and the next
+// rental of a car.
+type CleaningService interface {
+	// Tasks returns the open cleaning tasks, oldest first.
+	Tasks(ctx context.Context) ([]CleaningTask, error)
+	// Assign hands an open task to a staff member.
+	Assign(ctx context.Context, id int64, assignee string) (CleaningTask, error)
+	// Complete closes an open task, making its car bookable again.
+	Complete(ctx context.Context, id int64, cleanedBy string) (CleaningTask, error)
+}
+
+type cleaningService struct {
+	tasks store.CleaningRepository
+	sla   time.Duration
+	now   func() time.Time
+}
+
+// NewCleaningService returns a CleaningService backed by tasks that expects
+// cars to be cleaned within sla of their return.
+func NewCleaningService(tasks store.CleaningRepository, sla time.Duration) CleaningService {
+	return &cleaningService{tasks: tasks, sla: sla, now: time.Now}
+}
+
+func (s *cleaningService) Tasks(ctx context.Context) ([]CleaningTask, error) {
+	tasks, err := s.tasks.ListCleaningTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]CleaningTask, 0, len(tasks))
+	for _, task := range tasks {
+		due = append(due, s.withDeadline(task))
+	}
+	return due, nil
+}
+
+func (s *cleaningService) Assign(ctx context.Context, id int64, assignee string) (CleaningTask, error) {
+	assignee = strings.TrimSpace(assignee)
+	if assignee == "" {
+		return CleaningTask{}, validationError([]FieldError{{Field: "assignee", Message: "must not be empty"}})
+	}
+	if err := s.tasks.AssignCleaningTask(ctx, id, assignee); err != nil {
+		return CleaningTask{}, err
+	}
+	return s.get(ctx, id)
+}
+
+func (s *cleaningService) Complete(ctx context.Context, id int64, cleanedBy string) (CleaningTask, error) {
+	if err := s.tasks.CompleteCleaningTask(ctx, id, cleanedBy, s.now().UTC()); err != nil {
+		return CleaningTask{}, err
+	}
+	task, err := s.get(ctx, id)
+	if err != nil {
+		return CleaningTask{}, err
+	}
+	metrics.CleaningTurnaround.Observe(task.CleanedAt.Sub(task.ReturnedAt).Seconds())
+	if task.Overdue {
+		metrics.CleaningSLABreaches.Inc()
+	}
+	return task, nil
+}
+
+func (s *cleaningService) get(ctx context.Context, id int64) (CleaningTask, error) {
+	task, err := s.tasks.GetCleaningTask(ctx, id)
+	if err != nil {
+		return CleaningTask{}, err
+	}
+	return s.withDeadline(task), nil
+}
+
+// withDeadline adds the SLA deadline to task. A completed task is overdue
+// if it was cleaned late, an open one if the deadline has passed.
+func (s *cleaningService) withDeadline(task store.CleaningTask) CleaningTask {
+	due := task.ReturnedAt.Add(s.sla)
+	end := s.now()
+	if task.CleanedAt != nil {
+		end = *task.CleanedAt
+	}
+	return CleaningTask{CleaningTask: task, DueAt: due, Overdue: end.After(due)}
+}
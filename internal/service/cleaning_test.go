@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// memoryCleaning is an in-memory store.CleaningRepository.
+type memoryCleaning struct {
+	store.CleaningRepository
+	tasks []store.CleaningTask
+}
+
+func (m *memoryCleaning) ListCleaningTasks(context.Context) ([]store.CleaningTask, error) {
+	return m.tasks, nil
+}
+
+func (m *memoryCleaning) GetCleaningTask(_ context.Context, id int64) (store.CleaningTask, error) {
+	for _, task := range m.tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return store.CleaningTask{}, store.ErrCleaningTaskNotFound
+}
+
+func TestCleaningDeadlines(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cleanedLate := now.Add(-time.Hour)
+	repo := &memoryCleaning{tasks: []store.CleaningTask{
+		{ID: 1, ReturnedAt: now.Add(-3 * time.Hour)},
+		{ID: 2, ReturnedAt: now.Add(-time.Hour)},
+		{ID: 3, ReturnedAt: now.Add(-4 * time.Hour), CleanedAt: &cleanedLate},
+	}}
+	s := &cleaningService{tasks: repo, sla: 2 * time.Hour, now: func() time.Time { return now }}
+
+	tasks, err := s.Tasks(context.Background())
+	if err != nil {
+		t.Fatalf("tasks: %v", err)
+	}
+	want := []bool{true, false, true}
+	for i, task := range tasks {
+		if task.Overdue != want[i] || !task.DueAt.Equal(task.ReturnedAt.Add(2*time.Hour)) {
+			t.Errorf("task %d: due %v, overdue %v; want due 2h after return, overdue %v", task.ID, task.DueAt, task.Overdue, want[i])
+		}
+	}
+}
+
+func TestAssignCleaningValidation(t *testing.T) {
+	s := NewCleaningService(&memoryCleaning{}, time.Hour)
+	_, err := s.Assign(context.Background(), 1, "  ")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Field != "assignee" {
+		t.Fatalf("assign to nobody: got %v, want an assignee error", err)
+	}
+}
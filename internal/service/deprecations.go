@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"backendGo/internal/metrics"
+	"backendGo/internal/store"
+)
+
+// DeprecationService records use of deprecated API surfaces so they can be
+// removed once no client relies on them.
+type DeprecationService interface {
+	// Record counts one request by client to surface, an endpoint such as
+	// "GET /cars" or a field such as "GET /cars#rented".
+	Record(ctx context.Context, surface, client string) error
+	// Usage returns the recorded usage ordered by surface and client.
+	Usage(ctx context.Context) ([]store.DeprecatedUsage, error)
+}
+
+type deprecationService struct {
+	usage store.DeprecationRepository
+	now   func() time.Time
+}
+
+// NewDeprecationService returns a DeprecationService backed by usage.
+func NewDeprecationService(usage store.DeprecationRepository) DeprecationService {
+	return &deprecationService{usage: usage, now: time.Now}
+}
+
+func (s *deprecationService) Record(ctx context.Context, surface, client string) error {
+	metrics.DeprecatedRequests.WithLabelValues(surface).Inc()
+	return s.usage.RecordDeprecatedUsage(ctx, surface, client, s.now().UTC())
+}
+
+func (s *deprecationService) Usage(ctx context.Context) ([]store.DeprecatedUsage, error) {
+	return s.usage.ListDeprecatedUsage(ctx)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backendGo/internal/store"
+)
+
+var (
+	ErrDatabaseUnavailable = errors.New("database unavailable")
+	ErrMigrationsPending   = errors.New("migrations pending")
+)
+
+// HealthService reports whether the service is ready to take traffic.
+type HealthService interface {
+	// Ready returns nil when the database is reachable and fully migrated.
+	Ready(ctx context.Context) error
+}
+
+type healthService struct {
+	status store.StatusRepository
+}
+
+// NewHealthService returns a HealthService checking status.
+func NewHealthService(status store.StatusRepository) HealthService {
+	return &healthService{status: status}
+}
+
+func (s *healthService) Ready(ctx context.Context) error {
+	if err := s.status.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseUnavailable, err)
+	}
+	pending, err := s.status.PendingMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseUnavailable, err)
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d not applied", ErrMigrationsPending, pending)
+	}
+	return nil
+}
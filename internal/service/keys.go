@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// Errors returned by KeyService, besides ErrCarNotFound and
+// *ValidationError.
+var ErrKeyNotFound = store.ErrKeyNotFound
+
+// Reasons for a KeyAlert.
+const (
+	// AlertKeyMissing is raised for a key reported missing. A missing
+	// spare often goes unnoticed until the primary is lost too.
+	AlertKeyMissing = "missing"
+	// AlertNotCheckedIn is raised for a key still with a holder while its
+	// car is not rented out, as when it was not checked back in after a
+	// return.
+	AlertNotCheckedIn = "not_checked_in"
+)
+
+// KeyAlert is a key that needs looking after.
+type KeyAlert struct {
+	Key    store.CarKey `json:"key"`
+	Reason string       `json:"reason"`
+}
+
+// KeyService keeps track of the physical keys and fobs of the fleet.
+type KeyService interface {
+	// AddKey registers a key of the car with the given registration at its
+	// current location.
+	AddKey(ctx context.Context, registration, kind string, at store.KeyLocation, recordedBy string) (store.CarKey, error)
+	// Keys returns the keys of a car, or ErrCarNotFound.
+	Keys(ctx context.Context, registration string) ([]store.CarKey, error)
+	// HandOver records that a key moved to a new location and returns the
+	// updated key.
+	HandOver(ctx context.Context, keyID string, to store.KeyLocation, recordedBy string) (store.CarKey, error)
+	// Handovers returns the handover log of a key, oldest first, or
+	// ErrKeyNotFound.
+	Handovers(ctx context.Context, keyID string) ([]store.KeyHandover, error)
+	// Alerts returns the keys that are missing or were not checked back in
+	// after their car was returned. Keys of deleted cars raise no alerts.
+	Alerts(ctx context.Context) ([]KeyAlert, error)
+	// AlertCount returns how many alerts Alerts would return, without
+	// loading them.
+	AlertCount(ctx context.Context) (int, error)
+}
+
+type keyService struct {
+	keys store.KeyRepository
+	cars store.CarRepository
+	now  func() time.Time
+}
+
+// NewKeyService returns a KeyService backed by keys and cars.
+func NewKeyService(keys store.KeyRepository, cars store.CarRepository) KeyService {
+	return &keyService{keys: keys, cars: cars, now: time.Now}
+}
+
+func (s *keyService) AddKey(ctx context.Context, registration, kind string, at store.KeyLocation, recordedBy string) (store.CarKey, error) {
+	fields := locationErrors(&at)
+	if kind != store.KeyPrimary && kind != store.KeySpare {
+		fields = append(fields, FieldError{Field: "kind", Message: "must be primary or spare"})
+	}
+	if err := validationError(fields); err != nil {
+		return store.CarKey{}, err
+	}
+
+	id, err := newKeyID()
+	if err != nil {
+		return store.CarKey{}, err
+	}
+	key := store.CarKey{
+		ID:           id,
+		Registration: registration,
+		Kind:         kind,
+		KeyLocation:  at,
+		UpdatedAt:    s.now().UTC(),
+	}
+	if err := s.keys.AddKey(ctx, key, recordedBy); err != nil {
+		return store.CarKey{}, err
+	}
+	return key, nil
+}
+
+func (s *keyService) Keys(ctx context.Context, registration string) ([]store.CarKey, error) {
+	if _, err := s.cars.Get(ctx, registration); err != nil {
+		return nil, err
+	}
+	return s.keys.ListKeys(ctx, registration)
+}
+
+func (s *keyService) HandOver(ctx context.Context, keyID string, to store.KeyLocation, recordedBy string) (store.CarKey, error) {
+	if err := validationError(locationErrors(&to)); err != nil {
+		return store.CarKey{}, err
+	}
+	err := s.keys.HandOver(ctx, store.KeyHandover{KeyID: keyID, KeyLocation: to, RecordedBy: recordedBy, At: s.now().UTC()})
+	if err != nil {
+		return store.CarKey{}, err
+	}
+	return s.keys.GetKey(ctx, keyID)
+}
+
+func (s *keyService) Handovers(ctx context.Context, keyID string) ([]store.KeyHandover, error) {
+	if _, err := s.keys.GetKey(ctx, keyID); err != nil {
+		return nil, err
+	}
+	return s.keys.ListHandovers(ctx, keyID)
+}
+
+func (s *keyService) Alerts(ctx context.Context) ([]KeyAlert, error) {
+	keys, err := s.keys.ListKeyAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alerts := make([]KeyAlert, len(keys))
+	for i, key := range keys {
+		alerts[i] = KeyAlert{Key: key, Reason: AlertNotCheckedIn}
+		if key.Missing {
+			alerts[i].Reason = AlertKeyMissing
+		}
+	}
+	return alerts, nil
+}
+
+func (s *keyService) AlertCount(ctx context.Context) (int, error) {
+	return s.keys.CountKeyAlerts(ctx)
+}
+
+// locationErrors trims the holder and slot of loc and checks that exactly
+// one location is given.
+func locationErrors(loc *store.KeyLocation) []FieldError {
+	loc.Holder = strings.TrimSpace(loc.Holder)
+	loc.Slot = strings.TrimSpace(loc.Slot)
+	n := 0
+	for _, set := range []bool{loc.Holder != "", loc.Slot != "", loc.Missing} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return []FieldError{{Field: "location", Message: "exactly one of holder, slot or missing must be given"}}
+	}
+	return nil
+}
+
+// newKeyID returns a random key ID.
+func newKeyID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
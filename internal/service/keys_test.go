@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backendGo/internal/store"
+)
+
+// memoryKeys is an in-memory store.KeyRepository.
+type memoryKeys struct {
+	store.KeyRepository
+	keys []store.CarKey
+}
+
+func (m *memoryKeys) ListKeys(context.Context, string) ([]store.CarKey, error) { return m.keys, nil }
+
+// ListKeyAlerts returns every key; the store does the filtering.
+func (m *memoryKeys) ListKeyAlerts(context.Context) ([]store.CarKey, error) { return m.keys, nil }
+
+func (m *memoryKeys) CountKeyAlerts(context.Context) (int, error) { return len(m.keys), nil }
+
+func TestKeyAlerts(t *testing.T) {
+	keys := &memoryKeys{keys: []store.CarKey{
+		{ID: "lost-spare", Registration: "AAA111", Kind: store.KeySpare, KeyLocation: store.KeyLocation{Missing: true}},
+		{ID: "not-checked-in", Registration: "BBB222", Kind: store.KeyPrimary, KeyLocation: store.KeyLocation{Holder: "customer"}},
+	}}
+	s := NewKeyService(keys, &memoryRepository{})
+
+	alerts, err := s.Alerts(context.Background())
+	if err != nil {
+		t.Fatalf("alerts: %v", err)
+	}
+	if len(alerts) != 2 ||
+		alerts[0].Key.ID != "lost-spare" || alerts[0].Reason != AlertKeyMissing ||
+		alerts[1].Key.ID != "not-checked-in" || alerts[1].Reason != AlertNotCheckedIn {
+		t.Fatalf("alerts = %+v, want lost-spare missing and not-checked-in", alerts)
+	}
+	if n, err := s.AlertCount(context.Background()); err != nil || n != 2 {
+		t.Fatalf("alert count = %d, %v, want 2", n, err)
+	}
+}
+
+func TestKeyLocationValidation(t *testing.T) {
+	keys := NewKeyService(&memoryKeys{}, &memoryRepository{})
+	for _, loc := range []store.KeyLocation{
+		{},
+		{Holder: " "},
+		{Holder: "agent", Slot: "A1"},
+		{Slot: "A1", Missing: true},
+	} {
+		_, err := keys.HandOver(context.Background(), "k1", loc, "agent")
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("hand over to %+v: got %v, want a *ValidationError", loc, err)
+		}
+	}
+	_, err := keys.AddKey(context.Background(), "AAA111", "tertiary", store.KeyLocation{Slot: "A1"}, "admin")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Field != "kind" {
+		t.Fatalf("add key of unknown kind: got %v, want a kind error", err)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrOIDCLogin = errors.New("OIDC login failed")
+	// ErrNoRole is returned when none of the user's provider groups maps
+	// to a role.
+	ErrNoRole = errors.New("no role granted to user")
+)
+
+// OIDCConfig configures login through an OpenID Connect provider.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// GroupsClaim names the ID token claim listing the user's groups.
+	GroupsClaim string
+	// GroupRoles maps provider groups to roles. A user in several mapped
+	// groups gets the most privileged role.
+	GroupRoles map[string]Role
+}
+
+// OIDCService delegates staff authentication to an OIDC provider using the
+// authorization code flow with PKCE.
+type OIDCService interface {
+	// AuthCodeURL returns the provider URL to send the user to.
+	AuthCodeURL(state, nonce, verifier string) string
+	// Exchange redeems the authorization code returned to the callback,
+	// verifies the ID token and maps the user's groups to a role.
+	Exchange(ctx context.Context, code, nonce, verifier string) (Principal, error)
+}
+
+type oidcService struct {
+	oauth2      oauth2.Config
+	verifier    *oidc.IDTokenVerifier
+	groupsClaim string
+	groupRoles  map[string]Role
+}
+
+// NewOIDCService discovers the provider at cfg.IssuerURL and returns an
+// OIDCService for it.
+func NewOIDCService(ctx context.Context, cfg OIDCConfig) (OIDCService, error) {
+	for group, role := range cfg.GroupRoles {
+		if _, err := ParseRole(string(role)); err != nil {
+			return nil, fmt.Errorf("group %q: %w", group, err)
+		}
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC provider: %w", err)
+	}
+	return &oidcService{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		verifier:    provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		groupsClaim: cfg.GroupsClaim,
+		groupRoles:  cfg.GroupRoles,
+	}, nil
+}
+
+func (s *oidcService) AuthCodeURL(state, nonce, verifier string) string {
+	return s.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+func (s *oidcService) Exchange(ctx context.Context, code, nonce, verifier string) (Principal, error) {
+	token, err := s.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: exchanging code: %v", ErrOIDCLogin, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: no id_token in token response", ErrOIDCLogin)
+	}
+	idToken, err := s.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: verifying ID token: %v", ErrOIDCLogin, err)
+	}
+	if idToken.Nonce != nonce {
+		return Principal{}, fmt.Errorf("%w: nonce mismatch", ErrOIDCLogin)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: decoding claims: %v", ErrOIDCLogin, err)
+	}
+	username := idToken.Subject
+	for _, name := range []string{"preferred_username", "email"} {
+		if v, ok := claims[name].(string); ok && v != "" {
+			username = v
+			break
+		}
+	}
+
+	role, ok := s.role(claims[s.groupsClaim])
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: %s", ErrNoRole, username)
+	}
+	return Principal{Username: username, Role: role}, nil
+}
+
+// rolePrivilege orders roles from least to most privileged.
+var rolePrivilege = map[Role]int{RoleCustomer: 1, RoleAgent: 2, RoleAdmin: 3}
+
+// role returns the most privileged role mapped from groups, a JSON array
+// of group names.
+func (s *oidcService) role(groups interface{}) (Role, bool) {
+	list, _ := groups.([]interface{})
+	var best Role
+	for _, g := range list {
+		name, _ := g.(string)
+		if r, ok := s.groupRoles[name]; ok && rolePrivilege[r] > rolePrivilege[best] {
+			best = r
+		}
+	}
+	return best, best != ""
+}
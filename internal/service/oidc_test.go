@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeProvider is a minimal OIDC provider whose token endpoint returns an
+// ID token with the configured claims.
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	p := &fakeProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, p.claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Errorf("sign ID token: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "provider-token",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeProvider) login(t *testing.T, code, nonce string, groups ...interface{}) (Principal, error) {
+	t.Helper()
+
+	p.claims = jwt.MapClaims{
+		"iss":                p.URL,
+		"aud":                "carrental",
+		"sub":                "1234",
+		"preferred_username": "staffer",
+		"nonce":              "the-nonce",
+		"groups":             groups,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Minute).Unix(),
+	}
+	svc, err := NewOIDCService(context.Background(), OIDCConfig{
+		IssuerURL:   p.URL,
+		ClientID:    "carrental",
+		RedirectURL: "http://localhost/auth/oidc/callback",
+		GroupsClaim: "groups",
+		GroupRoles:  map[string]Role{"fleet-admins": RoleAdmin, "front-desk": RoleAgent},
+	})
+	if err != nil {
+		t.Fatalf("new OIDC service: %v", err)
+	}
+	return svc.Exchange(context.Background(), code, nonce, "the-verifier")
+}
+
+func TestOIDCExchangeMapsGroups(t *testing.T) {
+	p := newFakeProvider(t)
+
+	got, err := p.login(t, "good-code", "the-nonce", "front-desk", "fleet-admins", "unrelated")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if got != (Principal{Username: "staffer", Role: RoleAdmin}) {
+		t.Fatalf("principal = %+v, want staffer as admin", got)
+	}
+
+	got, err = p.login(t, "good-code", "the-nonce", "front-desk")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if got.Role != RoleAgent {
+		t.Fatalf("role = %q, want agent", got.Role)
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	p := newFakeProvider(t)
+
+	if _, err := p.login(t, "good-code", "the-nonce", "unrelated"); !errors.Is(err, ErrNoRole) {
+		t.Errorf("unmapped groups: got %v, want %v", err, ErrNoRole)
+	}
+	if _, err := p.login(t, "good-code", "other-nonce", "fleet-admins"); !errors.Is(err, ErrOIDCLogin) {
+		t.Errorf("wrong nonce: got %v, want %v", err, ErrOIDCLogin)
+	}
+	if _, err := p.login(t, "bad-code", "the-nonce", "fleet-admins"); !errors.Is(err, ErrOIDCLogin) {
+		t.Errorf("bad code: got %v, want %v", err, ErrOIDCLogin)
+	}
+}
+
+func TestNewOIDCServiceRejectsUnknownRole(t *testing.T) {
+	p := newFakeProvider(t)
+
+	_, err := NewOIDCService(context.Background(), OIDCConfig{
+		IssuerURL:  p.URL,
+		ClientID:   "carrental",
+		GroupRoles: map[string]Role{"owners": "owner"},
+	})
+	if !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidRole)
+	}
+}
@@ -0,0 +1,209 @@
+// Package service implements the car rental business operations on top of
+// a store.CarRepository.
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"backendGo/internal/metrics"
+	"backendGo/internal/store"
+)
+
+// Errors returned by RentalService. AddCar and UpdateCar also return a
+// *ValidationError for an invalid car, SearchCars for an empty query.
+var (
+	ErrCarNotFound      = store.ErrCarNotFound
+	ErrCarAlreadyRented = store.ErrCarAlreadyRented
+	ErrCarNotRented     = store.ErrCarNotRented
+	ErrCarNeedsCleaning = store.ErrCarNeedsCleaning
+	ErrCarNotDeleted    = store.ErrCarNotDeleted
+	ErrCarModified      = store.ErrCarModified
+)
+
+// ListAvailable returns DefaultCarsPerPage cars unless asked for another
+// page size, and never more than MaxCarsPerPage.
+const (
+	DefaultCarsPerPage = 100
+	MaxCarsPerPage     = 1000
+)
+
+// SearchCars returns DefaultSearchResults cars unless asked for fewer, and
+// never more than MaxSearchResults.
+const (
+	DefaultSearchResults = 20
+	MaxSearchResults     = 100
+)
+
+// CarPage is one page of a car listing.
+type CarPage struct {
+	Cars []store.Car
+	// Total is the number of cars on all pages together.
+	Total int
+	// Limit is the page size used, after defaulting and capping.
+	Limit int
+	// More reports whether cars follow this page.
+	More bool
+}
+
+// RentalService is the set of fleet and rental operations exposed by the API.
+type RentalService interface {
+	// ListAvailable returns the page of q.Limit cars from q.Offset or
+	// after q.After among those that are neither rented nor waiting to be
+	// cleaned, and with q.IncludeDeleted the deleted cars as well, that
+	// match the filters of q. A q.Rented filter replaces the availability
+	// condition, so that rented cars can be listed too. A zero Limit
+	// means DefaultCarsPerPage.
+	ListAvailable(ctx context.Context, q store.CarQuery) (CarPage, error)
+	// AvailableCars returns every car that can be rented now, unpaged, in
+	// registration order. It is for clients that need the whole picture
+	// in one response, such as long polls.
+	AvailableCars(ctx context.Context) ([]store.Car, error)
+	// SearchCars returns the cars, rented or not but not deleted, in whose
+	// make, model, color or registration every word of query starts a
+	// word, best match first. A zero limit means DefaultSearchResults.
+	SearchCars(ctx context.Context, query string, limit int) ([]store.Car, error)
+	// Car returns the car with the given registration, rented or not. A
+	// deleted car is returned only if includeDeleted is set.
+	Car(ctx context.Context, registration string, includeDeleted bool) (store.Car, error)
+	// ActiveRentals returns the number of cars currently rented.
+	ActiveRentals(ctx context.Context) (int, error)
+	// AddCar validates car and adds it to the fleet.
+	AddCar(ctx context.Context, car store.Car) error
+	// UpdateCar validates car and sets the model, mileage and vehicle
+	// details of the car with its registration, returning the updated
+	// car. The rented status is left alone; it changes only by renting
+	// and returning. A non-zero car.Version is the version the caller
+	// last saw, and ErrCarModified means the car has changed since.
+	UpdateCar(ctx context.Context, car store.Car) (store.Car, error)
+	// Rent rents out the car with the given registration.
+	Rent(ctx context.Context, registration string) error
+	// Return returns the car with the given registration, adding
+	// drivenMileage to its mileage. A negative drivenMileage is a
+	// *ValidationError, since odometers only count up. The car needs
+	// cleaning before it can be rented again.
+	Return(ctx context.Context, registration string, drivenMileage int) error
+	// DeleteCar takes a car out of the fleet. Its rentals, keys and other
+	// history are kept, and RestoreCar brings it back. A non-zero version
+	// makes it conditional like UpdateCar.
+	DeleteCar(ctx context.Context, registration string, version int64) error
+	// RestoreCar undoes DeleteCar and returns the restored car.
+	RestoreCar(ctx context.Context, registration string) (store.Car, error)
+}
+
+type rentalService struct {
+	cars store.CarRepository
+}
+
+// NewRentalService returns a RentalService backed by cars.
+func NewRentalService(cars store.CarRepository) RentalService {
+	return &rentalService{cars: cars}
+}
+
+func (s *rentalService) ListAvailable(ctx context.Context, q store.CarQuery) (CarPage, error) {
+	q.Available = q.Rented == nil
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultCarsPerPage
+	}
+	limit = min(limit, MaxCarsPerPage)
+
+	// One car more tells whether another page follows.
+	q.Limit = limit + 1
+	cars, err := s.cars.List(ctx, q)
+	if err != nil {
+		return CarPage{}, err
+	}
+	page := CarPage{Cars: cars, Limit: limit}
+	if len(cars) > limit {
+		page.Cars, page.More = cars[:limit], true
+	}
+	if page.Total, err = s.cars.Count(ctx, q); err != nil {
+		return CarPage{}, err
+	}
+	return page, nil
+}
+
+func (s *rentalService) AvailableCars(ctx context.Context) ([]store.Car, error) {
+	return s.cars.List(ctx, store.CarQuery{Available: true})
+}
+
+func (s *rentalService) SearchCars(ctx context.Context, query string, limit int) ([]store.Car, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, validationError([]FieldError{{Field: "q", Message: "must not be empty"}})
+	}
+	if limit == 0 {
+		limit = DefaultSearchResults
+	}
+	return s.cars.Search(ctx, query, min(limit, MaxSearchResults))
+}
+
+func (s *rentalService) Car(ctx context.Context, registration string, includeDeleted bool) (store.Car, error) {
+	car, err := s.cars.Get(ctx, registration)
+	if err == nil && car.DeletedAt != nil && !includeDeleted {
+		return store.Car{}, ErrCarNotFound
+	}
+	return car, err
+}
+
+func (s *rentalService) ActiveRentals(ctx context.Context) (int, error) {
+	return s.cars.CountRented(ctx)
+}
+
+func (s *rentalService) AddCar(ctx context.Context, car store.Car) error {
+	if err := validateCar(car); err != nil {
+		return err
+	}
+	return unknownCategory(s.cars.Add(ctx, car))
+}
+
+func (s *rentalService) UpdateCar(ctx context.Context, car store.Car) (store.Car, error) {
+	if err := validateCarDetails(car); err != nil {
+		return store.Car{}, err
+	}
+	if err := unknownCategory(s.cars.Update(ctx, car)); err != nil {
+		return store.Car{}, err
+	}
+	return s.cars.Get(ctx, car.Registration)
+}
+
+// unknownCategory reports a car in a missing category as invalid input,
+// and passes other errors through.
+func unknownCategory(err error) error {
+	if errors.Is(err, store.ErrCategoryNotFound) {
+		return validationError([]FieldError{{Field: "category", Message: "must be an existing category"}})
+	}
+	return err
+}
+
+func (s *rentalService) Rent(ctx context.Context, registration string) error {
+	if err := s.cars.MarkRented(ctx, registration); err != nil {
+		return err
+	}
+	metrics.CarsRented.Inc()
+	return nil
+}
+
+func (s *rentalService) Return(ctx context.Context, registration string, drivenMileage int) error {
+	if drivenMileage < 0 {
+		return validationError([]FieldError{{Field: "mileage", Message: "must not be negative"}})
+	}
+	if err := s.cars.MarkReturned(ctx, registration, drivenMileage); err != nil {
+		return err
+	}
+	metrics.CarReturns.Inc()
+	return nil
+}
+
+func (s *rentalService) DeleteCar(ctx context.Context, registration string, version int64) error {
+	return s.cars.Delete(ctx, registration, version, time.Now().UTC())
+}
+
+func (s *rentalService) RestoreCar(ctx context.Context, registration string) (store.Car, error) {
+	if err := s.cars.Restore(ctx, registration); err != nil {
+		return store.Car{}, err
+	}
+	return s.cars.Get(ctx, registration)
+}
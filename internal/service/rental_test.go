@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// memoryRepository is an in-memory store.CarRepository. It ignores the
+// filters of a query or search but records the last one.
+type memoryRepository struct {
+	store.CarRepository
+	cars        []store.Car
+	query       store.CarQuery
+	searchLimit int
+}
+
+func (m *memoryRepository) List(_ context.Context, q store.CarQuery) ([]store.Car, error) {
+	m.query = q
+	return m.cars, nil
+}
+
+func (m *memoryRepository) Count(context.Context, store.CarQuery) (int, error) { return 4000, nil }
+
+func (m *memoryRepository) Search(_ context.Context, _ string, limit int) ([]store.Car, error) {
+	m.searchLimit = limit
+	return m.cars, nil
+}
+
+func TestListAvailablePages(t *testing.T) {
+	repo := &memoryRepository{cars: []store.Car{{Registration: "AAA111"}, {Registration: "CCC333"}}}
+	rentals := NewRentalService(repo)
+
+	page, err := rentals.ListAvailable(context.Background(), store.CarQuery{})
+	if err != nil {
+		t.Fatalf("list available: %v", err)
+	}
+	if !repo.query.Available || repo.query.Limit != DefaultCarsPerPage+1 || page.Limit != DefaultCarsPerPage || len(page.Cars) != 2 {
+		t.Fatalf("got %+v for query %+v, want both cars of an available-only query with limit %d", page, repo.query, DefaultCarsPerPage+1)
+	}
+
+	page, err = rentals.ListAvailable(context.Background(), store.CarQuery{Limit: 5000, Offset: 10})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if repo.query.Limit != MaxCarsPerPage+1 || repo.query.Offset != 10 || page.Total != 4000 || page.Limit != MaxCarsPerPage || page.More {
+		t.Fatalf("got %+v for query %+v, want the counted total, no more cars and limit %d", page, repo.query, MaxCarsPerPage+1)
+	}
+
+	page, err = rentals.ListAvailable(context.Background(), store.CarQuery{Limit: 1, After: "AAA000"})
+	if err != nil {
+		t.Fatalf("list after cursor: %v", err)
+	}
+	if repo.query.After != "AAA000" || len(page.Cars) != 1 || !page.More {
+		t.Fatalf("got %+v for query %+v, want one car and more to follow", page, repo.query)
+	}
+}
+
+func TestSearchCarsLimit(t *testing.T) {
+	repo := &memoryRepository{}
+	rentals := NewRentalService(repo)
+	for limit, want := range map[int]int{0: DefaultSearchResults, 5: 5, 5000: MaxSearchResults} {
+		if _, err := rentals.SearchCars(context.Background(), "corolla", limit); err != nil || repo.searchLimit != want {
+			t.Errorf("search with limit %d: store limit %d, %v; want %d", limit, repo.searchLimit, err, want)
+		}
+	}
+
+	var invalid *ValidationError
+	if _, err := rentals.SearchCars(context.Background(), " ", 0); !errors.As(err, &invalid) {
+		t.Errorf("blank search: %v, want a validation error", err)
+	}
+}
+
+func TestReturnRejectsNegativeMileage(t *testing.T) {
+	rentals := NewRentalService(&memoryRepository{})
+
+	var invalid *ValidationError
+	if err := rentals.Return(context.Background(), "AAA111", -1); !errors.As(err, &invalid) || invalid.Fields[0].Field != "mileage" {
+		t.Fatalf("return with negative mileage: %v, want a validation error on mileage", err)
+	}
+}
+
+func TestValidateCar(t *testing.T) {
+	tests := []struct {
+		car  store.Car
+		want []string
+	}{
+		{store.Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}, nil},
+		{store.Car{Model: "Honda Civic", Registration: "AB-12-CD"}, nil},
+		{store.Car{}, []string{"model", "registration"}},
+		{store.Car{Model: "  ", Registration: "DEF456"}, []string{"model"}},
+		{store.Car{Model: "Honda Civic", Registration: "def456"}, []string{"registration"}},
+		{store.Car{Model: "Honda Civic", Registration: "DEF 456"}, []string{"registration"}},
+		{store.Car{Model: "Honda Civic", Registration: "-DEF456"}, []string{"registration"}},
+		{store.Car{Model: "Honda Civic", Registration: "ABCDEFGHIJKLMNOPQ"}, []string{"registration"}},
+		{store.Car{Model: "Honda Civic", Registration: "DEF456", Mileage: -1}, []string{"mileage"}},
+		{store.Car{Model: "Civic", Registration: "DEF456", Make: "Honda", Year: 2024, Color: "blue", VIN: "1M8GDM9AXKP042788",
+			Category: "economy", Transmission: "manual", Fuel: "hybrid", Seats: 5}, nil},
+		{store.Car{Model: "Civic", Registration: "DEF456", Year: 1899, Seats: 51}, []string{"year", "seats"}},
+		{store.Car{Model: "Civic", Registration: "DEF456", Seats: -1}, []string{"seats"}},
+		{store.Car{Model: strings.Repeat("x", maxModelLength+1), Registration: "DEF456"}, []string{"model"}},
+		{store.Car{Model: strings.Repeat("x", maxModelLength), Registration: "DEF456"}, nil},
+		{store.Car{Model: "Civic", Registration: "DEF456", Year: time.Now().Year() + 2}, []string{"year"}},
+		{store.Car{Model: "Civic", Registration: "DEF456", Transmission: "cvt", Fuel: "coal"}, []string{"transmission", "fuel"}},
+		{store.Car{Model: "Civic", Registration: "DEF456", Make: strings.Repeat("x", 65), Color: strings.Repeat("x", 33)}, []string{"make", "color"}},
+		{store.Car{Model: "Civic", Registration: "DEF456", VIN: "1M8GDM9A1KP042788"}, []string{"vin"}},
+	}
+	for _, tt := range tests {
+		err := validateCar(tt.car)
+		var got []string
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			for _, f := range invalid.Fields {
+				got = append(got, f.Field)
+			}
+		} else if err != nil {
+			t.Fatalf("validateCar(%+v) = %v, want a *ValidationError", tt.car, err)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("validateCar(%+v) rejects %v, want %v", tt.car, got, tt.want)
+		}
+	}
+}
+
+func TestValidVIN(t *testing.T) {
+	tests := []struct {
+		vin  string
+		want bool
+	}{
+		{"1M8GDM9AXKP042788", true},
+		{"11111111111111111", true},
+		{"1HGCM82633A004352", true},
+		{"1HGCM82643A004352", false},
+		{"1M8GDM9AXKP04278", false},
+		{"1M8GDM9AXKP042788X", false},
+		{"1M8GDM9AXKO042788", false},
+		{"1m8gdm9axkp042788", false},
+	}
+	for _, tt := range tests {
+		if got := validVIN(tt.vin); got != tt.want {
+			t.Errorf("validVIN(%q) = %v, want %v", tt.vin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"backendGo/internal/store"
+)
+
+// FieldError explains why one field of an input was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned for input that fails validation. It lists
+// every invalid field, not just the first.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "invalid input: " + strings.Join(msgs, "; ")
+}
+
+// registrationPattern matches registrations: uppercase letters and
+// digits, optionally with inner hyphens, 2 to 16 characters long. They
+// appear in URL paths, so spaces are not allowed.
+var registrationPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{0,14}[A-Z0-9]$`)
+
+// validateCar returns a *ValidationError if car cannot be added to the
+// fleet, or nil.
+func validateCar(car store.Car) error {
+	fields := detailErrors(car)
+	if !registrationPattern.MatchString(car.Registration) {
+		fields = append(fields, FieldError{Field: "registration", Message: "must be 2 to 16 uppercase letters or digits, optionally with inner hyphens"})
+	}
+	return validationError(fields)
+}
+
+// validateCarDetails is validateCar for updates, which keep the
+// registration the car already has.
+func validateCarDetails(car store.Car) error {
+	return validationError(detailErrors(car))
+}
+
+// detailErrors checks the fields of car that can change after it is added.
+func detailErrors(car store.Car) []FieldError {
+	var fields []FieldError
+	switch {
+	case strings.TrimSpace(car.Model) == "":
+		fields = append(fields, FieldError{Field: "model", Message: "must not be empty"})
+	case len(car.Model) > maxModelLength:
+		fields = append(fields, FieldError{Field: "model", Message: fmt.Sprintf("must be at most %d bytes", maxModelLength)})
+	}
+	if car.Mileage < 0 {
+		fields = append(fields, FieldError{Field: "mileage", Message: "must not be negative"})
+	}
+	return append(fields, vehicleErrors(car)...)
+}
+
+// Values of the enumerated vehicle fields. Categories are checked by the
+// store, as they can be added.
+var (
+	carTransmissions = []string{"manual", "automatic"}
+	carFuels         = []string{"petrol", "diesel", "hybrid", "electric"}
+)
+
+// Bounds of the car fields. Model years run up to a year ahead of the
+// calendar.
+const (
+	minCarYear  = 1900
+	maxCarSeats = 50
+	// maxModelLength, maxMakeLength and maxColorLength are the column
+	// sizes on MySQL.
+	maxModelLength = 255
+	maxMakeLength  = 64
+	maxColorLength = 32
+)
+
+// vehicleErrors checks the optional vehicle fields of car, which are left
+// alone when zero.
+func vehicleErrors(car store.Car) []FieldError {
+	var fields []FieldError
+	invalid := func(field, message string) {
+		fields = append(fields, FieldError{Field: field, Message: message})
+	}
+	if len(car.Make) > maxMakeLength {
+		invalid("make", fmt.Sprintf("must be at most %d bytes", maxMakeLength))
+	}
+	if maxYear := time.Now().Year() + 1; car.Year != 0 && (car.Year < minCarYear || car.Year > maxYear) {
+		invalid("year", fmt.Sprintf("must be between %d and %d", minCarYear, maxYear))
+	}
+	if len(car.Color) > maxColorLength {
+		invalid("color", fmt.Sprintf("must be at most %d bytes", maxColorLength))
+	}
+	if car.VIN != "" && !validVIN(car.VIN) {
+		invalid("vin", "must be 17 uppercase letters or digits, without I, O or Q, with a valid check digit")
+	}
+	for _, e := range []struct {
+		field, value string
+		values       []string
+	}{
+		{"transmission", car.Transmission, carTransmissions},
+		{"fuel", car.Fuel, carFuels},
+	} {
+		if e.value != "" && !slices.Contains(e.values, e.value) {
+			invalid(e.field, "must be one of "+strings.Join(e.values, ", "))
+		}
+	}
+	if car.Seats < 0 || car.Seats > maxCarSeats {
+		invalid("seats", fmt.Sprintf("must be between 1 and %d, or 0 if unknown", maxCarSeats))
+	}
+	return fields
+}
+
+// vinWeights are the position weights of the ISO 3779 check digit, which
+// is the ninth character.
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// validVIN reports whether vin is a well-formed vehicle identification
+// number with a correct check digit.
+func validVIN(vin string) bool {
+	if len(vin) != len(vinWeights) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(vin); i++ {
+		v, ok := vinValue(vin[i])
+		if !ok {
+			return false
+		}
+		sum += v * vinWeights[i]
+	}
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+	return vin[8] == check
+}
+
+// vinValue transliterates one VIN character. I, O and Q are not used, as
+// they look like 1 and 0.
+func vinValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'N':
+		return int(c-'J') + 1, true
+	case c == 'P':
+		return 7, true
+	case c == 'R':
+		return 9, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	}
+	return 0, false
+}
+
+func validationError(fields []FieldError) error {
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+func (s *SQLRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	ctx, done := s.startOperation(ctx, "list_categories")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT name, description FROM categories ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.Name, &c.Description); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (s *SQLRepository) GetCategory(ctx context.Context, name string) (Category, error) {
+	ctx, done := s.startOperation(ctx, "get_category")
+	defer done()
+
+	var c Category
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT name, description FROM categories WHERE name = ?"), name).
+		Scan(&c.Name, &c.Description)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Category{}, ErrCategoryNotFound
+	}
+	return c, err
+}
+
+func (s *SQLRepository) AddCategory(ctx context.Context, c Category) error {
+	ctx, done := s.startOperation(ctx, "add_category")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// Only the primary key sees a category added concurrently under
+		// the same name.
+		_, err := tx.ExecContext(ctx, s.dialect.rebind("INSERT INTO categories (name, description) VALUES (?, ?)"), c.Name, c.Description)
+		if isUniqueViolation(err) {
+			return ErrCategoryExists
+		}
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCategory, c.Name, OpCreate)
+	})
+}
+
+func (s *SQLRepository) UpdateCategory(ctx context.Context, c Category) error {
+	ctx, done := s.startOperation(ctx, "update_category")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE categories SET description = ? WHERE name = ?"), c.Description, c.Name)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(res, ErrCategoryNotFound); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCategory, c.Name, OpUpdate)
+	})
+}
+
+func (s *SQLRepository) DeleteCategory(ctx context.Context, name string) error {
+	ctx, done := s.startOperation(ctx, "delete_category")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// Deleted cars count too, as restoring one would otherwise leave
+		// it in a missing category.
+		var inUse bool
+		err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM cars WHERE category = ?)"), name).Scan(&inUse)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrCategoryInUse
+		}
+		// The foreign key from cars refuses the delete if a car was put
+		// in the category after the check.
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM categories WHERE name = ?"), name)
+		if isForeignKeyViolation(err) {
+			return ErrCategoryInUse
+		}
+		if err != nil {
+			return err
+		}
+		if err := requireRow(res, ErrCategoryNotFound); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCategory, name, OpDelete)
+	})
+}
+
+func (s *SQLRepository) CategoryAvailability(ctx context.Context) ([]CategoryAvailability, error) {
+	ctx, done := s.startOperation(ctx, "category_availability")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(`SELECT categories.name, categories.description, COUNT(cars.registration),
+			COALESCE(SUM(CASE WHEN cars.rented = ? AND cars.needs_cleaning = ? THEN 1 ELSE 0 END), 0)
+		FROM categories LEFT JOIN cars ON cars.category = categories.name AND cars.deleted_at IS NULL
+		GROUP BY categories.name, categories.description
+		ORDER BY categories.name`), false, false)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []CategoryAvailability
+	for rows.Next() {
+		var c CategoryAvailability
+		if err := rows.Scan(&c.Name, &c.Description, &c.Total, &c.Available); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// categoryExists reports whether the category with the given name exists,
+// as seen by tx.
+func (s *SQLRepository) categoryExists(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM categories WHERE name = ?)"), name).Scan(&exists)
+	return exists, err
+}
+
+// requireCategory returns ErrCategoryNotFound unless a car may be put in
+// the named category: one that exists, or none. A category deleted after
+// the check is caught by the foreign key from cars instead.
+func (s *SQLRepository) requireCategory(ctx context.Context, tx *sql.Tx, name string) error {
+	if name == "" {
+		return nil
+	}
+	exists, err := s.categoryExists(ctx, tx, name)
+	if err == nil && !exists {
+		err = ErrCategoryNotFound
+	}
+	return err
+}
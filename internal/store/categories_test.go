@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCategories(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	if err := repo.Add(ctx, Car{Model: "Golf", Registration: "ABC123", Category: "compact"}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("add car in unknown category: got %v, want %v", err, ErrCategoryNotFound)
+	}
+	if err := repo.AddCategory(ctx, Category{Name: "compact", Description: "Small cars"}); err != nil {
+		t.Fatalf("add category: %v", err)
+	}
+	if err := repo.AddCategory(ctx, Category{Name: "compact", Description: "Again"}); !errors.Is(err, ErrCategoryExists) {
+		t.Fatalf("add category twice: got %v, want %v", err, ErrCategoryExists)
+	}
+	if err := repo.Add(ctx, Car{Model: "Golf", Registration: "ABC123", Category: "compact"}); err != nil {
+		t.Fatalf("add car: %v", err)
+	}
+	if err := repo.UpdateCategory(ctx, Category{Name: "compact", Description: "Small cars"}); err != nil {
+		t.Fatalf("update category unchanged: %v", err)
+	}
+	if err := repo.UpdateCategory(ctx, Category{Name: "nope", Description: "x"}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("update unknown category: got %v, want %v", err, ErrCategoryNotFound)
+	}
+
+	// A deleted car still holds on to its category.
+	if err := repo.Delete(ctx, "ABC123", 0, time.Now()); err != nil {
+		t.Fatalf("delete car: %v", err)
+	}
+	if err := repo.DeleteCategory(ctx, "compact"); !errors.Is(err, ErrCategoryInUse) {
+		t.Fatalf("delete category in use: got %v, want %v", err, ErrCategoryInUse)
+	}
+	counts, err := repo.CategoryAvailability(ctx)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	for _, c := range counts {
+		if c.Total != 0 || c.Available != 0 {
+			t.Errorf("%s counts %d of %d cars, want none: deleted cars are not counted", c.Name, c.Available, c.Total)
+		}
+	}
+
+	if err := repo.DeleteCategory(ctx, "van"); err != nil {
+		t.Fatalf("delete unused category: %v", err)
+	}
+	if _, err := repo.GetCategory(ctx, "van"); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("get deleted category: got %v, want %v", err, ErrCategoryNotFound)
+	}
+	if categories, err := repo.ListCategories(ctx); err != nil || len(categories) != 4 {
+		t.Fatalf("categories = %v, %v; want the seeds without van, plus compact", categories, err)
+	}
+}
+
+// TestCategoryForeignKey writes past the checks, as a concurrent
+// transaction can, to show that the database still keeps every car's
+// category.
+func TestCategoryForeignKey(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	if err := repo.Add(ctx, Car{Model: "Golf", Registration: "ABC123", Category: "suv"}); err != nil {
+		t.Fatalf("add car: %v", err)
+	}
+	if err := repo.Add(ctx, Car{Model: "Polo", Registration: "DEF456"}); err != nil {
+		t.Fatalf("add car without category: %v", err)
+	}
+	var uncategorized bool
+	if err := repo.db.QueryRowContext(ctx, "SELECT category IS NULL FROM cars WHERE registration = 'DEF456'").Scan(&uncategorized); err != nil || !uncategorized {
+		t.Fatalf("car without category stored as NULL = %v, %v; want true", uncategorized, err)
+	}
+	if car, err := repo.Get(ctx, "DEF456"); err != nil || car.Category != "" {
+		t.Fatalf("car without category = %+v, %v; want an empty category", car, err)
+	}
+
+	_, err := repo.db.ExecContext(ctx, "DELETE FROM categories WHERE name = 'suv'")
+	if !isForeignKeyViolation(err) {
+		t.Fatalf("delete category in use: got %v, want a foreign key violation", err)
+	}
+	_, err = repo.db.ExecContext(ctx, "UPDATE cars SET category = 'nope' WHERE registration = 'DEF456'")
+	if !isForeignKeyViolation(err) {
+		t.Fatalf("car in missing category: got %v, want a foreign key violation", err)
+	}
+	_, err = repo.db.ExecContext(ctx, "INSERT INTO categories (name, description) VALUES ('suv', 'Again')")
+	if !isUniqueViolation(err) {
+		t.Fatalf("duplicate category: got %v, want a unique violation", err)
+	}
+}
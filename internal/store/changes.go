@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// changeCommitLag is how long a missing change ID is waited for. IDs are
+// handed out when a change is recorded, but Postgres and MySQL transactions
+// may commit in a different order, so a gap below a newer change can still
+// fill. A gap older than this is taken for a rolled back transaction.
+const changeCommitLag = 30 * time.Second
+
+// recordChange appends an entry to the change log as part of tx.
+func (s *SQLRepository) recordChange(ctx context.Context, tx *sql.Tx, entityType, entityID, operation string) error {
+	_, err := tx.ExecContext(ctx, s.dialect.rebind(`INSERT INTO changes (entity_type, entity_id, operation, changed_at)
+		VALUES (?, ?, ?, ?)`), entityType, entityID, operation, time.Now().UTC())
+	return err
+}
+
+// changeWatermark returns the highest change ID below which no change can
+// still appear: the ID before the first gap that is younger than
+// changeCommitLag. Readers that stop there never skip a late commit.
+func (s *SQLRepository) changeWatermark(ctx context.Context) (int64, error) {
+	cutoff := time.Now().UTC().Add(-changeCommitLag)
+	var settled sql.NullInt64
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT MAX(id) FROM changes WHERE changed_at <= ?"), cutoff).Scan(&settled)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind("SELECT id FROM changes WHERE changed_at > ? ORDER BY id"), cutoff)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	watermark := settled.Int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		if id > watermark+1 {
+			break
+		}
+		watermark = max(watermark, id)
+	}
+	return watermark, rows.Err()
+}
+
+func (s *SQLRepository) ListChanges(ctx context.Context, since int64, limit int) ([]Change, error) {
+	ctx, done := s.startOperation(ctx, "list_changes")
+	defer done()
+
+	watermark, err := s.changeWatermark(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(`SELECT id, entity_type, entity_id, operation, changed_at
+		FROM changes WHERE id > ? AND id <= ? ORDER BY id LIMIT ?`), since, watermark, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.ID, &c.EntityType, &c.EntityID, &c.Operation, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func (s *SQLRepository) LatestChangeID(ctx context.Context, entityType string) (int64, error) {
+	ctx, done := s.startOperation(ctx, "latest_change_id")
+	defer done()
+
+	watermark, err := s.changeWatermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var id sql.NullInt64
+	err = s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT MAX(id) FROM changes WHERE entity_type = ? AND id <= ?"), entityType, watermark).Scan(&id)
+	return id.Int64, err
+}
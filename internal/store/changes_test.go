@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLatestChangeID(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	if id, err := repo.LatestChangeID(ctx, EntityCar); err != nil || id != 0 {
+		t.Fatalf("empty log = %d, %v, want 0", id, err)
+	}
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+
+	changes, err := repo.ListChanges(ctx, 0, 10)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("changes = %+v, %v, want two", changes, err)
+	}
+	if id, err := repo.LatestChangeID(ctx, EntityCar); err != nil || id != changes[1].ID {
+		t.Fatalf("latest car change = %d, %v, want %d", id, err, changes[1].ID)
+	}
+	if id, err := repo.LatestChangeID(ctx, "driver"); err != nil || id != 0 {
+		t.Fatalf("latest change of another type = %d, %v, want 0", id, err)
+	}
+}
+
+func TestListChangesWaitsForGaps(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	// Change 3 stands for a transaction that took its ID before change 4
+	// but commits after it.
+	settled := time.Now().UTC().Add(-time.Hour)
+	insert := func(id int64, at time.Time) {
+		t.Helper()
+		_, err := repo.db.ExecContext(ctx, "INSERT INTO changes (id, entity_type, entity_id, operation, changed_at) VALUES (?, ?, ?, ?, ?)",
+			id, EntityCar, "DEF456", OpUpdate, at)
+		if err != nil {
+			t.Fatalf("insert change %d: %v", id, err)
+		}
+	}
+	ids := func(since int64) []int64 {
+		t.Helper()
+		changes, err := repo.ListChanges(ctx, since, 10)
+		if err != nil {
+			t.Fatalf("list changes: %v", err)
+		}
+		var ids []int64
+		for _, c := range changes {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	insert(1, settled)
+	insert(2, settled)
+	insert(4, time.Now().UTC())
+	if got := ids(0); !slices.Equal(got, []int64{1, 2}) {
+		t.Fatalf("changes before the gap fills = %v, want [1 2]", got)
+	}
+	if id, err := repo.LatestChangeID(ctx, EntityCar); err != nil || id != 2 {
+		t.Fatalf("latest change before the gap fills = %d, %v, want 2", id, err)
+	}
+
+	insert(3, time.Now().UTC())
+	if got := ids(2); !slices.Equal(got, []int64{3, 4}) {
+		t.Fatalf("changes after the gap fills = %v, want [3 4]", got)
+	}
+
+	// A gap that outlives changeCommitLag is a rolled back change.
+	insert(6, settled)
+	if got := ids(4); !slices.Equal(got, []int64{6}) {
+		t.Fatalf("changes after a stale gap = %v, want [6]", got)
+	}
+}
+
+func TestChangesCoverEntities(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	now := time.Now().UTC()
+	steps := []func() error{
+		func() error { return repo.AddCategory(ctx, Category{Name: "compact", Description: "Small cars"}) },
+		func() error {
+			return repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200, Category: "compact"})
+		},
+		func() error {
+			return repo.AddKey(ctx, CarKey{ID: "k1", Registration: "DEF456", Kind: KeyPrimary, KeyLocation: KeyLocation{Slot: "A1"}, UpdatedAt: now}, "alice")
+		},
+		func() error {
+			return repo.HandOver(ctx, KeyHandover{KeyID: "k1", KeyLocation: KeyLocation{Holder: "bob"}, RecordedBy: "alice", At: now})
+		},
+		func() error { return repo.MarkRented(ctx, "DEF456") },
+		func() error { return repo.MarkReturned(ctx, "DEF456", 10) },
+		func() error { return repo.AssignCleaningTask(ctx, 1, "carol") },
+		func() error { return repo.CompleteCleaningTask(ctx, 1, "carol", now) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+
+	changes, err := repo.ListChanges(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("list changes: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.EntityType+" "+c.EntityID+" "+c.Operation)
+	}
+	want := []string{
+		"category compact create",
+		"car DEF456 create",
+		"key k1 create",
+		"key k1 update",
+		"car DEF456 update",
+		"cleaning_task 1 create",
+		"car DEF456 update",
+		"cleaning_task 1 update",
+		"cleaning_task 1 update",
+		"car DEF456 update",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const cleaningColumns = "id, registration, assignee, returned_at, cleaned_at, cleaned_by"
+
+func scanCleaningTask(row rowScanner) (CleaningTask, error) {
+	var (
+		t         CleaningTask
+		cleanedAt sql.NullTime
+	)
+	err := row.Scan(&t.ID, &t.Registration, &t.Assignee, &t.ReturnedAt, &cleanedAt, &t.CleanedBy)
+	if cleanedAt.Valid {
+		t.CleanedAt = &cleanedAt.Time
+	}
+	return t, err
+}
+
+// openCleaningTask flags a returned car as needing cleaning and opens an
+// unassigned task for it as part of tx.
+func (s *SQLRepository) openCleaningTask(ctx context.Context, tx *sql.Tx, registration string, returnedAt time.Time) error {
+	_, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cars SET needs_cleaning = ?, version = version + 1 WHERE registration = ?"), true, registration)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, s.dialect.rebind(`INSERT INTO cleaning_tasks (registration, assignee, returned_at, cleaned_by)
+		VALUES (?, '', ?, '')`), registration, returnedAt)
+	if err != nil {
+		return err
+	}
+	// A car has at most one open task, as it cannot be rented, and so
+	// returned, until that task is completed.
+	var id int64
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("SELECT id FROM cleaning_tasks WHERE registration = ? AND cleaned_at IS NULL"), registration).Scan(&id)
+	if err != nil {
+		return err
+	}
+	return s.recordChange(ctx, tx, EntityCleaningTask, strconv.FormatInt(id, 10), OpCreate)
+}
+
+func (s *SQLRepository) ListCleaningTasks(ctx context.Context) ([]CleaningTask, error) {
+	ctx, done := s.startOperation(ctx, "list_cleaning_tasks")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+cleaningColumns+" FROM cleaning_tasks WHERE cleaned_at IS NULL ORDER BY returned_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []CleaningTask
+	for rows.Next() {
+		task, err := scanCleaningTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *SQLRepository) GetCleaningTask(ctx context.Context, id int64) (CleaningTask, error) {
+	ctx, done := s.startOperation(ctx, "get_cleaning_task")
+	defer done()
+
+	task, err := scanCleaningTask(s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT "+cleaningColumns+" FROM cleaning_tasks WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return CleaningTask{}, ErrCleaningTaskNotFound
+	}
+	return task, err
+}
+
+func (s *SQLRepository) AssignCleaningTask(ctx context.Context, id int64, assignee string) error {
+	ctx, done := s.startOperation(ctx, "assign_cleaning_task")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cleaning_tasks SET assignee = ? WHERE id = ? AND cleaned_at IS NULL"), assignee, id)
+		if err != nil {
+			return err
+		}
+		if err := s.requireOpenTask(ctx, tx, res, id); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCleaningTask, strconv.FormatInt(id, 10), OpUpdate)
+	})
+}
+
+func (s *SQLRepository) CompleteCleaningTask(ctx context.Context, id int64, cleanedBy string, at time.Time) error {
+	ctx, done := s.startOperation(ctx, "complete_cleaning_task")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var (
+			registration string
+			cleanedAt    sql.NullTime
+		)
+		err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT registration, cleaned_at FROM cleaning_tasks WHERE id = ?"), id).
+			Scan(&registration, &cleanedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrCleaningTaskNotFound
+		case err != nil:
+			return err
+		case cleanedAt.Valid:
+			return ErrCleaningTaskDone
+		}
+
+		// The cleaned_at guard keeps a concurrent completion from closing
+		// the task twice.
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cleaning_tasks SET cleaned_at = ?, cleaned_by = ? WHERE id = ? AND cleaned_at IS NULL"),
+			at, cleanedBy, id)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(res, ErrCleaningTaskDone); err != nil {
+			return err
+		}
+		if err := s.recordChange(ctx, tx, EntityCleaningTask, strconv.FormatInt(id, 10), OpUpdate); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.dialect.rebind("UPDATE cars SET needs_cleaning = ?, version = version + 1 WHERE registration = ?"), false, registration)
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCar, registration, OpUpdate)
+	})
+}
+
+// requireOpenTask returns ErrCleaningTaskNotFound or ErrCleaningTaskDone
+// if an update of open task id matched no rows because the task does not
+// exist or is completed.
+func (s *SQLRepository) requireOpenTask(ctx context.Context, tx *sql.Tx, res sql.Result, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+	var cleanedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("SELECT cleaned_at FROM cleaning_tasks WHERE id = ?"), id).Scan(&cleanedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrCleaningTaskNotFound
+	case err != nil:
+		return err
+	case cleanedAt.Valid:
+		return ErrCleaningTaskDone
+	}
+	return nil
+}
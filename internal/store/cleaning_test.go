@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCleaningTasks(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	if err := repo.MarkReturned(ctx, "DEF456", 10); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+
+	if car, err := repo.Get(ctx, "DEF456"); err != nil || car.Rented || !car.NeedsCleaning {
+		t.Fatalf("car after return = %+v, %v, want needing cleaning", car, err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); !errors.Is(err, ErrCarNeedsCleaning) {
+		t.Fatalf("rent uncleaned car: got %v, want %v", err, ErrCarNeedsCleaning)
+	}
+
+	tasks, err := repo.ListCleaningTasks(ctx)
+	if err != nil || len(tasks) != 1 || tasks[0].Registration != "DEF456" || tasks[0].CleanedAt != nil {
+		t.Fatalf("open tasks = %+v, %v, want one for DEF456", tasks, err)
+	}
+	id := tasks[0].ID
+	for i := 0; i < 2; i++ {
+		// Assigning the same person again leaves the row unchanged.
+		if err := repo.AssignCleaningTask(ctx, id, "bob"); err != nil {
+			t.Fatalf("assign %d: %v", i, err)
+		}
+	}
+	if err := repo.AssignCleaningTask(ctx, id+1, "bob"); !errors.Is(err, ErrCleaningTaskNotFound) {
+		t.Fatalf("assign unknown task: got %v, want %v", err, ErrCleaningTaskNotFound)
+	}
+
+	at := time.Now().UTC().Truncate(time.Second)
+	if err := repo.CompleteCleaningTask(ctx, id, "bob", at); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if err := repo.CompleteCleaningTask(ctx, id, "bob", at); !errors.Is(err, ErrCleaningTaskDone) {
+		t.Fatalf("complete twice: got %v, want %v", err, ErrCleaningTaskDone)
+	}
+	if err := repo.AssignCleaningTask(ctx, id, "eve"); !errors.Is(err, ErrCleaningTaskDone) {
+		t.Fatalf("assign completed task: got %v, want %v", err, ErrCleaningTaskDone)
+	}
+	if err := repo.CompleteCleaningTask(ctx, id+1, "bob", at); !errors.Is(err, ErrCleaningTaskNotFound) {
+		t.Fatalf("complete unknown task: got %v, want %v", err, ErrCleaningTaskNotFound)
+	}
+
+	task, err := repo.GetCleaningTask(ctx, id)
+	if err != nil || task.Assignee != "bob" || task.CleanedBy != "bob" || task.CleanedAt == nil || !task.CleanedAt.Equal(at) {
+		t.Fatalf("completed task = %+v, %v, want cleaned by bob at %v", task, err, at)
+	}
+	if open, err := repo.ListCleaningTasks(ctx); err != nil || len(open) != 0 {
+		t.Fatalf("open tasks after completion = %+v, %v, want none", open, err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent cleaned car: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+func (s *SQLRepository) RecordDeprecatedUsage(ctx context.Context, surface, client string, at time.Time) error {
+	ctx, done := s.startOperation(ctx, "record_deprecated_usage")
+	defer done()
+
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind(s.dialect.recordDeprecatedUsage), surface, client, at, at)
+	return err
+}
+
+func (s *SQLRepository) ListDeprecatedUsage(ctx context.Context) ([]DeprecatedUsage, error) {
+	ctx, done := s.startOperation(ctx, "list_deprecated_usage")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT surface, client, requests, first_seen, last_seen
+		FROM deprecated_usage ORDER BY surface, client`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []DeprecatedUsage
+	for rows.Next() {
+		var u DeprecatedUsage
+		if err := rows.Scan(&u.Surface, &u.Client, &u.Requests, &u.FirstSeen, &u.LastSeen); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
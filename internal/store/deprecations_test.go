@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecordDeprecatedUsage(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, client := range []string{"alice", "alice", "bob"} {
+		if err := repo.RecordDeprecatedUsage(ctx, "GET /old", client, first.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("record #%d: %v", i+1, err)
+		}
+	}
+
+	usage, err := repo.ListDeprecatedUsage(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("got %+v, want one row per client", usage)
+	}
+	alice := usage[0]
+	if alice.Client != "alice" || alice.Requests != 2 || !alice.FirstSeen.Equal(first) || !alice.LastSeen.Equal(first.Add(time.Hour)) {
+		t.Fatalf("alice = %+v, want 2 requests from %v to %v", alice, first, first.Add(time.Hour))
+	}
+	if usage[1].Client != "bob" || usage[1].Requests != 1 {
+		t.Fatalf("bob = %+v, want 1 request", usage[1])
+	}
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// dialect holds the SQL that differs between database backends. Queries in
+// this package are written with ? placeholders and rebound per dialect.
+type dialect struct {
+	// name selects the migrations directory.
+	name string
+	// bindvar returns the placeholder for the n-th (1-based) argument.
+	bindvar func(n int) string
+	// prepareDSN, if set, adjusts the DSN before the database is opened.
+	prepareDSN func(dsn string) (string, error)
+	// lockMigrations, if set, waits for the session lock of conn that
+	// keeps other instances from migrating, and returns its release.
+	// SQLite databases are not shared between instances.
+	lockMigrations func(ctx context.Context, conn *sql.Conn) (unlock func() error, err error)
+
+	seedCars string
+	// recordDeprecatedUsage inserts a deprecated_usage row or counts one
+	// more request on the existing one.
+	recordDeprecatedUsage string
+	// searchCars returns the query for up to limit undeleted cars matching
+	// all of terms, best match first.
+	searchCars func(terms []string, limit int) (string, []interface{})
+}
+
+var dialects = map[string]dialect{
+	"sqlite": {
+		name:       "sqlite",
+		bindvar:    func(int) string { return "?" },
+		prepareDSN: prepareSQLiteDSN,
+		seedCars: `INSERT OR IGNORE INTO cars (model, registration, mileage, rented)
+			VALUES ('Tesla M3', 'BTS812', 6003, 0)`,
+		recordDeprecatedUsage: upsertDeprecatedUsage,
+		searchCars:            searchFTS5,
+	},
+	"postgres": {
+		name:           "postgres",
+		bindvar:        func(n int) string { return "$" + strconv.Itoa(n) },
+		lockMigrations: lockPostgresMigrations,
+		seedCars: `INSERT INTO cars (model, registration, mileage, rented)
+			VALUES ('Tesla M3', 'BTS812', 6003, FALSE)
+			ON CONFLICT (registration) DO NOTHING`,
+		recordDeprecatedUsage: upsertDeprecatedUsage,
+		searchCars:            searchTrigram,
+	},
+	"mysql": {
+		name:           "mysql",
+		bindvar:        func(int) string { return "?" },
+		prepareDSN:     prepareMySQLDSN,
+		lockMigrations: lockMySQLMigrations,
+		seedCars: `INSERT IGNORE INTO cars (model, registration, mileage, rented)
+			VALUES ('Tesla M3', 'BTS812', 6003, FALSE)`,
+		recordDeprecatedUsage: `INSERT INTO deprecated_usage (surface, client, requests, first_seen, last_seen)
+			VALUES (?, ?, 1, ?, ?)
+			ON DUPLICATE KEY UPDATE requests = requests + 1, last_seen = VALUES(last_seen)`,
+		searchCars: searchFulltext,
+	},
+}
+
+// upsertDeprecatedUsage is shared by SQLite and Postgres, which both
+// support ON CONFLICT.
+const upsertDeprecatedUsage = `INSERT INTO deprecated_usage (surface, client, requests, first_seen, last_seen)
+	VALUES (?, ?, 1, ?, ?)
+	ON CONFLICT (surface, client) DO UPDATE SET
+		requests = deprecated_usage.requests + 1,
+		last_seen = excluded.last_seen`
+
+// prepareSQLiteDSN turns on foreign key enforcement, which SQLite leaves
+// off unless each connection asks for it.
+func prepareSQLiteDSN(dsn string) (string, error) {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)", nil
+}
+
+// prepareMySQLDSN makes MySQL report matched rather than changed rows from
+// UPDATE, as SQLite and Postgres do. Without it an update that leaves a row
+// unchanged would look like a missing row. It also enables multi-statement
+// queries so migration files can be run in one call, and scans DATETIME
+// columns into time.Time.
+func prepareMySQLDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.ClientFoundRows = true
+	cfg.MultiStatements = true
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
+
+func lookupDialect(driver string) (dialect, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return dialect{}, fmt.Errorf("unsupported database driver %q", driver)
+	}
+	return d, nil
+}
+
+// rebind replaces the ? placeholders in query with the dialect's bindvars.
+func (d dialect) rebind(query string) string {
+	if d.bindvar(1) == "?" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.bindvar(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isUniqueViolation reports whether err is a driver's duplicate key error.
+func isUniqueViolation(err error) bool {
+	// SQLite reports a duplicate primary key and a duplicate unique key
+	// with different extended codes.
+	return isDriverError(err, "23505", []uint16{1062}, []int{1555, 2067})
+}
+
+// isForeignKeyViolation reports whether err is a driver's error for a
+// reference to a missing row or a delete of a referenced one.
+func isForeignKeyViolation(err error) bool {
+	return isDriverError(err, "23503", []uint16{1451, 1452}, []int{787})
+}
+
+// isDriverError reports whether err is a Postgres error with code pgCode, a
+// MySQL error with one of mysqlNumbers or a SQLite error with one of the
+// extended sqliteCodes.
+func isDriverError(err error, pgCode pq.ErrorCode, mysqlNumbers []uint16, sqliteCodes []int) bool {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgCode
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return slices.Contains(mysqlNumbers, mysqlErr.Number)
+	}
+	// The SQLite driver's error type is only reachable through the method.
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		return slices.Contains(sqliteCodes, sqliteErr.Code())
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	query := "UPDATE cars SET rented = ? WHERE registration = ? AND rented = ?"
+
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"sqlite", query},
+		{"postgres", "UPDATE cars SET rented = $1 WHERE registration = $2 AND rented = $3"},
+		{"mysql", query},
+	}
+	for _, tt := range tests {
+		d, err := lookupDialect(tt.driver)
+		if err != nil {
+			t.Fatalf("lookup %s: %v", tt.driver, err)
+		}
+		if got := d.rebind(query); got != tt.want {
+			t.Errorf("%s: rebind = %q, want %q", tt.driver, got, tt.want)
+		}
+	}
+}
+
+func TestLookupUnknownDialect(t *testing.T) {
+	if _, err := lookupDialect("oracle"); err == nil {
+		t.Fatal("expected an error for an unsupported driver")
+	}
+}
+
+func TestPrepareMySQLDSN(t *testing.T) {
+	dsn, err := prepareMySQLDSN("fleet:secret@tcp(db:3306)/cars")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if !strings.Contains(dsn, "clientFoundRows=true") {
+		t.Fatalf("dsn = %q, want clientFoundRows=true", dsn)
+	}
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"backendGo/internal/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("backendGo/internal/store")
+
+// startOperation starts a span for a storage operation. The returned
+// function ends the span and records the operation's duration.
+func (s *SQLRepository) startOperation(ctx context.Context, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "store."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", s.dialect.name)),
+	)
+	return ctx, func() {
+		span.End()
+		metrics.ObserveQuery(operation, start)
+	}
+}
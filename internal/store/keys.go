@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const keyColumns = "id, registration, kind, holder, slot, missing, updated_at"
+
+func scanKey(row rowScanner) (CarKey, error) {
+	var k CarKey
+	err := row.Scan(&k.ID, &k.Registration, &k.Kind, &k.Holder, &k.Slot, &k.Missing, &k.UpdatedAt)
+	return k, err
+}
+
+func (s *SQLRepository) AddKey(ctx context.Context, key CarKey, recordedBy string) error {
+	ctx, done := s.startOperation(ctx, "add_key")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM cars WHERE registration = ? AND deleted_at IS NULL)"), key.Registration).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCarNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, s.dialect.rebind("INSERT INTO car_keys ("+keyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)"),
+			key.ID, key.Registration, key.Kind, key.Holder, key.Slot, key.Missing, key.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.logHandover(ctx, tx, KeyHandover{KeyID: key.ID, KeyLocation: key.KeyLocation, RecordedBy: recordedBy, At: key.UpdatedAt}); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityKey, key.ID, OpCreate)
+	})
+}
+
+func (s *SQLRepository) GetKey(ctx context.Context, id string) (CarKey, error) {
+	ctx, done := s.startOperation(ctx, "get_key")
+	defer done()
+
+	key, err := scanKey(s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT "+keyColumns+" FROM car_keys WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return CarKey{}, ErrKeyNotFound
+	}
+	return key, err
+}
+
+func (s *SQLRepository) ListKeys(ctx context.Context, registration string) ([]CarKey, error) {
+	ctx, done := s.startOperation(ctx, "list_keys")
+	defer done()
+
+	query, args := "SELECT "+keyColumns+" FROM car_keys", []interface{}{}
+	if registration != "" {
+		query, args = query+" WHERE registration = ?", append(args, registration)
+	}
+	return s.queryKeys(ctx, query, args...)
+}
+
+// keyAlertsFrom selects the keys that need looking after; its arguments are
+// true and false.
+const keyAlertsFrom = ` FROM car_keys k WHERE EXISTS (
+	SELECT 1 FROM cars c WHERE c.registration = k.registration AND c.deleted_at IS NULL
+		AND (k.missing = ? OR (k.holder <> '' AND c.rented = ?)))`
+
+func (s *SQLRepository) ListKeyAlerts(ctx context.Context) ([]CarKey, error) {
+	ctx, done := s.startOperation(ctx, "list_key_alerts")
+	defer done()
+
+	return s.queryKeys(ctx, "SELECT "+keyColumns+keyAlertsFrom, true, false)
+}
+
+func (s *SQLRepository) CountKeyAlerts(ctx context.Context) (int, error) {
+	ctx, done := s.startOperation(ctx, "count_key_alerts")
+	defer done()
+
+	var n int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT COUNT(*)"+keyAlertsFrom), true, false).Scan(&n)
+	return n, err
+}
+
+// queryKeys runs a query selecting keyColumns from car_keys, ordered by
+// registration and kind.
+func (s *SQLRepository) queryKeys(ctx context.Context, query string, args ...interface{}) ([]CarKey, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(query+" ORDER BY registration, kind, id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []CarKey
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLRepository) HandOver(ctx context.Context, h KeyHandover) error {
+	ctx, done := s.startOperation(ctx, "hand_over_key")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE car_keys SET holder = ?, slot = ?, missing = ?, updated_at = ? WHERE id = ?"),
+			h.Holder, h.Slot, h.Missing, h.At, h.KeyID)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(res, ErrKeyNotFound); err != nil {
+			return err
+		}
+		if err := s.logHandover(ctx, tx, h); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityKey, h.KeyID, OpUpdate)
+	})
+}
+
+// logHandover appends h to the handover log as part of tx.
+func (s *SQLRepository) logHandover(ctx context.Context, tx *sql.Tx, h KeyHandover) error {
+	_, err := tx.ExecContext(ctx, s.dialect.rebind(`INSERT INTO key_handovers (key_id, holder, slot, missing, recorded_by, handed_over_at)
+		VALUES (?, ?, ?, ?, ?, ?)`), h.KeyID, h.Holder, h.Slot, h.Missing, h.RecordedBy, h.At)
+	return err
+}
+
+func (s *SQLRepository) ListHandovers(ctx context.Context, keyID string) ([]KeyHandover, error) {
+	ctx, done := s.startOperation(ctx, "list_key_handovers")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(`SELECT key_id, holder, slot, missing, recorded_by, handed_over_at
+		FROM key_handovers WHERE key_id = ? ORDER BY id`), keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var handovers []KeyHandover
+	for rows.Next() {
+		var h KeyHandover
+		if err := rows.Scan(&h.KeyID, &h.Holder, &h.Slot, &h.Missing, &h.RecordedBy, &h.At); err != nil {
+			return nil, err
+		}
+		handovers = append(handovers, h)
+	}
+	return handovers, rows.Err()
+}
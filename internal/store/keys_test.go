@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyHandovers(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	key := CarKey{ID: "k1", Registration: "DEF456", Kind: KeyPrimary, KeyLocation: KeyLocation{Slot: "A1"}, UpdatedAt: now}
+	if err := repo.AddKey(ctx, key, "admin"); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("add key of unknown car: got %v, want %v", err, ErrCarNotFound)
+	}
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}); err != nil {
+		t.Fatalf("add car: %v", err)
+	}
+	if err := repo.AddKey(ctx, key, "admin"); err != nil {
+		t.Fatalf("add key: %v", err)
+	}
+	spare := CarKey{ID: "k2", Registration: "DEF456", Kind: KeySpare, KeyLocation: KeyLocation{Slot: "A2"}, UpdatedAt: now}
+	if err := repo.AddKey(ctx, spare, "admin"); err != nil {
+		t.Fatalf("add spare: %v", err)
+	}
+
+	later := now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		// The second handover leaves the row unchanged and must still work.
+		if err := repo.HandOver(ctx, KeyHandover{KeyID: "k1", KeyLocation: KeyLocation{Holder: "agent"}, RecordedBy: "agent", At: later}); err != nil {
+			t.Fatalf("hand over %d: %v", i, err)
+		}
+	}
+	if err := repo.HandOver(ctx, KeyHandover{KeyID: "nope", KeyLocation: KeyLocation{Missing: true}, At: later}); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("hand over unknown key: got %v, want %v", err, ErrKeyNotFound)
+	}
+
+	got, err := repo.GetKey(ctx, "k1")
+	if err != nil {
+		t.Fatalf("get key: %v", err)
+	}
+	if got.Holder != "agent" || got.Slot != "" || !got.UpdatedAt.Equal(later) {
+		t.Fatalf("key = %+v, want held by agent since %v", got, later)
+	}
+	if _, err := repo.GetKey(ctx, "nope"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("get unknown key: got %v, want %v", err, ErrKeyNotFound)
+	}
+
+	keys, err := repo.ListKeys(ctx, "DEF456")
+	if err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "k1" || keys[1].ID != "k2" {
+		t.Fatalf("keys = %+v, want k1 and k2", keys)
+	}
+	if all, err := repo.ListKeys(ctx, ""); err != nil || len(all) != 2 {
+		t.Fatalf("list all keys = %+v, %v, want 2", all, err)
+	}
+
+	handovers, err := repo.ListHandovers(ctx, "k1")
+	if err != nil {
+		t.Fatalf("list handovers: %v", err)
+	}
+	if len(handovers) != 3 || handovers[0].Slot != "A1" || handovers[0].RecordedBy != "admin" || handovers[2].Holder != "agent" {
+		t.Fatalf("handovers = %+v, want slot A1 then agent twice", handovers)
+	}
+}
+
+func TestKeyAlerts(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, car := range []Car{
+		{Model: "Toyota Corolla", Registration: "RENTED", Rented: true},
+		{Model: "Honda Civic", Registration: "PARKED"},
+		{Model: "Ford Focus", Registration: "DELETED"},
+	} {
+		if err := repo.Add(ctx, car); err != nil {
+			t.Fatalf("add %s: %v", car.Registration, err)
+		}
+	}
+	for _, key := range []CarKey{
+		{ID: "with-customer", Registration: "RENTED", Kind: KeyPrimary, KeyLocation: KeyLocation{Holder: "customer"}},
+		{ID: "lost-spare", Registration: "RENTED", Kind: KeySpare, KeyLocation: KeyLocation{Missing: true}},
+		{ID: "not-checked-in", Registration: "PARKED", Kind: KeyPrimary, KeyLocation: KeyLocation{Holder: "customer"}},
+		{ID: "in-lockbox", Registration: "PARKED", Kind: KeySpare, KeyLocation: KeyLocation{Slot: "B2"}},
+		{ID: "of-deleted-car", Registration: "DELETED", Kind: KeyPrimary, KeyLocation: KeyLocation{Holder: "agent"}},
+	} {
+		key.UpdatedAt = now
+		if err := repo.AddKey(ctx, key, "admin"); err != nil {
+			t.Fatalf("add key %s: %v", key.ID, err)
+		}
+	}
+	if err := repo.Delete(ctx, "DELETED", 0, now); err != nil {
+		t.Fatalf("delete car: %v", err)
+	}
+
+	keys, err := repo.ListKeyAlerts(ctx)
+	if err != nil {
+		t.Fatalf("list key alerts: %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "not-checked-in" || keys[1].ID != "lost-spare" {
+		t.Fatalf("alerts = %+v, want not-checked-in and lost-spare", keys)
+	}
+	if n, err := repo.CountKeyAlerts(ctx); err != nil || n != 2 {
+		t.Fatalf("count key alerts = %d, %v, want 2", n, err)
+	}
+}
@@ -0,0 +1,288 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration files live in migrations/<dialect>/ and are named
+// NNNN_description.up.sql and NNNN_description.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations returns the migrations for dialect d, sorted by version.
+func loadMigrations(d dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.name)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := cutMigrationSuffix(file)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", file)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+
+		body, err := fs.ReadFile(migrationFiles, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %04d_%s: missing up file", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+func cutMigrationSuffix(file string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(file, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(file, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// migrationConn is the connection migrations run on: a *sql.DB, or the
+// *sql.Conn holding the migration lock.
+type migrationConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// migrationLockID names the session lock that Postgres and MySQL
+// instances take before migrating.
+const (
+	migrationLockID   = 7241901
+	migrationLockName = "carrental.migrations"
+)
+
+func lockPostgresMigrations(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID)
+		return err
+	}, nil
+}
+
+func lockMySQLMigrations(ctx context.Context, conn *sql.Conn) (func() error, error) {
+	// A timeout of -1 waits as long as another instance is migrating.
+	var held sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", migrationLockName).Scan(&held); err != nil {
+		return nil, err
+	}
+	if held.Int64 != 1 {
+		return nil, errors.New("could not take the migration lock")
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", migrationLockName)
+		return err
+	}, nil
+}
+
+// lockMigrations returns a connection on which no other instance migrates
+// until release is called. The lock belongs to the connection's session,
+// so every migration statement runs on it.
+func (s *SQLRepository) lockMigrations(ctx context.Context) (conn *sql.Conn, release func() error, err error) {
+	conn, err = s.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.dialect.lockMigrations == nil {
+		return conn, conn.Close, nil
+	}
+	unlock, err := s.dialect.lockMigrations(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, func() error {
+		// Closing a connection returns it to the pool, which would keep
+		// the lock, so it is released first.
+		return errors.Join(unlock(), conn.Close())
+	}, nil
+}
+
+func createMigrationsTable(ctx context.Context, db migrationConn) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY
+	)`)
+	return err
+}
+
+// appliedVersions returns the set of applied migration versions.
+func appliedVersions(ctx context.Context, db migrationConn) (map[int]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// Migrate applies all pending migrations in version order. On Postgres and
+// MySQL it first waits for any other instance that is migrating the same
+// database. Each migration runs in its own transaction together with its
+// version bookkeeping, but MySQL commits every DDL statement at once: a
+// failed MySQL migration is not rolled back, leaving the statements before
+// the failure applied and the version unrecorded, and must be repaired by
+// hand before migrating again.
+func (s *SQLRepository) Migrate(ctx context.Context) (err error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return err
+	}
+	conn, release, err := s.lockMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, release()) }()
+
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := runMigration(ctx, conn, m.up, s.dialect.rebind("INSERT INTO schema_migrations (version) VALUES (?)"), m.version); err != nil {
+			return fmt.Errorf("migration %04d_%s up: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown rolls back the most recently applied migration, taking the
+// same lock as Migrate. It does nothing when no migration has been
+// applied.
+func (s *SQLRepository) MigrateDown(ctx context.Context) (err error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return err
+	}
+	conn, release, err := s.lockMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, release()) }()
+
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if !applied[m.version] {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("migration %04d_%s: missing down file", m.version, m.name)
+		}
+		if err := runMigration(ctx, conn, m.down, s.dialect.rebind("DELETE FROM schema_migrations WHERE version = ?"), m.version); err != nil {
+			return fmt.Errorf("migration %04d_%s down: %w", m.version, m.name, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// PendingMigrations returns the number of migrations not yet applied.
+func (s *SQLRepository) PendingMigrations(ctx context.Context) (int, error) {
+	migrations, err := loadMigrations(s.dialect)
+	if err != nil {
+		return 0, err
+	}
+	applied, err := appliedVersions(ctx, s.db)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if !applied[m.version] {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// runMigration executes script and then the bookkeeping statement in one
+// transaction, which MySQL commits early at the first DDL statement.
+func runMigration(ctx context.Context, db migrationConn, script, bookkeeping string, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func tableExists(t *testing.T, repo *SQLRepository, name string) bool {
+	t.Helper()
+
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", name).Scan(&exists)
+	if err != nil {
+		t.Fatalf("check table %s: %v", name, err)
+	}
+	return exists
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for name, d := range dialects {
+		migrations, err := loadMigrations(d)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("%s: no migrations", name)
+		}
+		for i, m := range migrations {
+			if m.version != i+1 {
+				t.Errorf("%s: migration %d has version %d, want %d", name, i, m.version, i+1)
+			}
+			if m.down == "" {
+				t.Errorf("%s: migration %04d_%s has no down file", name, m.version, m.name)
+			}
+		}
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	migrations, err := loadMigrations(repo.dialect)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	// newTestRepository already migrated; running again must be a no-op.
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+	applied, err := appliedVersions(ctx, repo.db)
+	if err != nil {
+		t.Fatalf("applied versions: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	for range migrations {
+		if err := repo.MigrateDown(ctx); err != nil {
+			t.Fatalf("migrate down: %v", err)
+		}
+	}
+	if tableExists(t, repo, "cars") {
+		t.Fatal("cars table still exists after rolling back every migration")
+	}
+	if pending, err := repo.PendingMigrations(ctx); err != nil || pending != len(migrations) {
+		t.Fatalf("pending migrations = %d, %v, want %d", pending, err, len(migrations))
+	}
+	if err := repo.MigrateDown(ctx); err != nil {
+		t.Fatalf("migrate down with nothing applied: %v", err)
+	}
+
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if !tableExists(t, repo, "cars") {
+		t.Fatal("cars table missing after migrating up")
+	}
+	if pending, err := repo.PendingMigrations(ctx); err != nil || pending != 0 {
+		t.Fatalf("pending migrations = %d, %v, want 0", pending, err)
+	}
+}
+
+func TestMigrateWaitsForLock(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	repo, err := NewSQLRepository(db, "sqlite")
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+
+	// Another instance is migrating.
+	_, release, err := repo.lockMigrations(context.Background())
+	if err != nil {
+		t.Fatalf("lock migrations: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- repo.Migrate(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("migrate finished while locked: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if pending, err := repo.PendingMigrations(context.Background()); err != nil || pending != 0 {
+		t.Fatalf("pending migrations = %d, %v, want 0", pending, err)
+	}
+}
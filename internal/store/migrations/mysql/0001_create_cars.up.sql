@@ -0,0 +1,8 @@
+-- MySQL cannot index TEXT columns without a prefix length, and BOOLEAN is an
+-- alias for TINYINT(1) that the driver scans into bool.
+CREATE TABLE IF NOT EXISTS cars (
+	model VARCHAR(255),
+	registration VARCHAR(32) PRIMARY KEY,
+	mileage INTEGER,
+	rented BOOLEAN
+);
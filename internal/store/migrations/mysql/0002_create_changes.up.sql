@@ -0,0 +1,7 @@
+CREATE TABLE changes (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	entity_type VARCHAR(64) NOT NULL,
+	entity_id VARCHAR(255) NOT NULL,
+	operation VARCHAR(16) NOT NULL,
+	changed_at DATETIME(6) NOT NULL
+);
@@ -0,0 +1,5 @@
+CREATE TABLE users (
+	username VARCHAR(64) PRIMARY KEY,
+	password_hash VARCHAR(255) NOT NULL,
+	created_at DATETIME(6) NOT NULL
+);
@@ -0,0 +1,8 @@
+CREATE TABLE deprecated_usage (
+	surface VARCHAR(255) NOT NULL,
+	client VARCHAR(64) NOT NULL,
+	requests BIGINT NOT NULL,
+	first_seen DATETIME(6) NOT NULL,
+	last_seen DATETIME(6) NOT NULL,
+	PRIMARY KEY (surface, client)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE sessions (
+	id VARCHAR(64) PRIMARY KEY,
+	username VARCHAR(255) NOT NULL,
+	role VARCHAR(16) NOT NULL,
+	refresh_token_hash CHAR(64) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	last_used_at DATETIME(6) NOT NULL,
+	expires_at DATETIME(6) NOT NULL,
+	revoked_at DATETIME(6) NULL,
+	INDEX sessions_username (username)
+);
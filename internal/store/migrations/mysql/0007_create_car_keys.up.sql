@@ -0,0 +1,20 @@
+CREATE TABLE car_keys (
+	id VARCHAR(64) PRIMARY KEY,
+	registration VARCHAR(32) NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	holder VARCHAR(255) NOT NULL,
+	slot VARCHAR(64) NOT NULL,
+	missing BOOLEAN NOT NULL,
+	updated_at DATETIME(6) NOT NULL,
+	INDEX car_keys_registration (registration)
+);
+CREATE TABLE key_handovers (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	key_id VARCHAR(64) NOT NULL,
+	holder VARCHAR(255) NOT NULL,
+	slot VARCHAR(64) NOT NULL,
+	missing BOOLEAN NOT NULL,
+	recorded_by VARCHAR(255) NOT NULL,
+	handed_over_at DATETIME(6) NOT NULL,
+	INDEX key_handovers_key_id (key_id)
+);
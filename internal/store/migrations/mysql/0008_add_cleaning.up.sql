@@ -0,0 +1,10 @@
+ALTER TABLE cars ADD COLUMN needs_cleaning BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE TABLE cleaning_tasks (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	registration VARCHAR(32) NOT NULL,
+	assignee VARCHAR(255) NOT NULL,
+	returned_at DATETIME(6) NOT NULL,
+	cleaned_at DATETIME(6) NULL,
+	cleaned_by VARCHAR(255) NOT NULL,
+	INDEX cleaning_tasks_registration (registration)
+);
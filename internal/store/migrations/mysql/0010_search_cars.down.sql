@@ -0,0 +1 @@
+ALTER TABLE cars DROP INDEX cars_search;
@@ -0,0 +1,3 @@
+-- Searches filter with LIKE and rank with MATCH, which needs a FULLTEXT
+-- index on exactly the matched columns.
+ALTER TABLE cars ADD FULLTEXT INDEX cars_search (model, registration);
@@ -0,0 +1,11 @@
+ALTER TABLE cars
+	DROP INDEX cars_search,
+	DROP COLUMN seats,
+	DROP COLUMN fuel,
+	DROP COLUMN transmission,
+	DROP COLUMN category,
+	DROP COLUMN vin,
+	DROP COLUMN color,
+	DROP COLUMN year,
+	DROP COLUMN make,
+	ADD FULLTEXT INDEX cars_search (model, registration);
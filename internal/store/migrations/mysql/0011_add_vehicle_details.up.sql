@@ -0,0 +1,14 @@
+-- Existing cars get empty details, which mean unknown. TEXT columns cannot
+-- have defaults, so the sizes bound the values the API accepts.
+ALTER TABLE cars
+	ADD COLUMN make VARCHAR(64) NOT NULL DEFAULT '',
+	ADD COLUMN year INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN color VARCHAR(32) NOT NULL DEFAULT '',
+	ADD COLUMN vin VARCHAR(17) NOT NULL DEFAULT '',
+	ADD COLUMN category VARCHAR(16) NOT NULL DEFAULT '',
+	ADD COLUMN transmission VARCHAR(16) NOT NULL DEFAULT '',
+	ADD COLUMN fuel VARCHAR(16) NOT NULL DEFAULT '',
+	ADD COLUMN seats INTEGER NOT NULL DEFAULT 0,
+	-- Search the make and color as well.
+	DROP INDEX cars_search,
+	ADD FULLTEXT INDEX cars_search (make, model, color, registration);
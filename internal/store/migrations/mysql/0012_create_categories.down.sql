@@ -0,0 +1,2 @@
+DROP INDEX cars_category ON cars;
+DROP TABLE categories;
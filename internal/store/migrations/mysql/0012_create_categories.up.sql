@@ -0,0 +1,12 @@
+-- The categories accepted for cars so far become the initial rows, so
+-- every car's category exists. Names fit cars.category.
+CREATE TABLE categories (
+	name VARCHAR(16) PRIMARY KEY,
+	description VARCHAR(255) NOT NULL
+);
+INSERT INTO categories (name, description) VALUES
+	('economy', 'Small, fuel-efficient cars'),
+	('suv', 'Sport utility vehicles'),
+	('luxury', 'Premium and executive cars'),
+	('van', 'Vans and people carriers');
+CREATE INDEX cars_category ON cars (category);
@@ -0,0 +1 @@
+CREATE INDEX changes_changed_at ON changes (changed_at);
@@ -0,0 +1,3 @@
+ALTER TABLE cars DROP FOREIGN KEY cars_category_fkey;
+UPDATE cars SET category = '' WHERE category IS NULL;
+ALTER TABLE cars MODIFY category VARCHAR(16) NOT NULL DEFAULT '';
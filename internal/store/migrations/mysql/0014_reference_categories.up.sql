@@ -0,0 +1,6 @@
+-- Cars without a category hold NULL rather than '', so that every other
+-- value can reference a category row. The cars_category index serves the
+-- foreign key.
+ALTER TABLE cars MODIFY category VARCHAR(16) NULL DEFAULT NULL;
+UPDATE cars SET category = NULL WHERE category = '';
+ALTER TABLE cars ADD CONSTRAINT cars_category_fkey FOREIGN KEY (category) REFERENCES categories (name);
@@ -0,0 +1,3 @@
+-- Only the Postgres search index needs changing; FULLTEXT indexes skip
+-- NULL columns.
+DO 0;
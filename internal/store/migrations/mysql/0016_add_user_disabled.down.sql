@@ -0,0 +1,4 @@
+-- Placeholders hold the flag for accounts known only to an OIDC provider
+-- and have no use without it.
+DELETE FROM users WHERE password_hash = '';
+ALTER TABLE users DROP COLUMN disabled;
@@ -0,0 +1,7 @@
+CREATE TABLE changes (
+	id BIGSERIAL PRIMARY KEY,
+	entity_type TEXT NOT NULL,
+	entity_id TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,5 @@
+CREATE TABLE users (
+	username TEXT PRIMARY KEY,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer';
+-- Accounts created before roles existed could do everything.
+UPDATE users SET role = 'admin';
@@ -0,0 +1 @@
+DROP TABLE deprecated_usage;
@@ -0,0 +1,8 @@
+CREATE TABLE deprecated_usage (
+	surface TEXT NOT NULL,
+	client TEXT NOT NULL,
+	requests BIGINT NOT NULL,
+	first_seen TIMESTAMPTZ NOT NULL,
+	last_seen TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (surface, client)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE car_keys (
+	id TEXT PRIMARY KEY,
+	registration TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	holder TEXT NOT NULL,
+	slot TEXT NOT NULL,
+	missing BOOLEAN NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX car_keys_registration ON car_keys (registration);
+CREATE TABLE key_handovers (
+	id BIGSERIAL PRIMARY KEY,
+	key_id TEXT NOT NULL,
+	holder TEXT NOT NULL,
+	slot TEXT NOT NULL,
+	missing BOOLEAN NOT NULL,
+	recorded_by TEXT NOT NULL,
+	handed_over_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX key_handovers_key_id ON key_handovers (key_id);
@@ -0,0 +1,10 @@
+ALTER TABLE cars ADD COLUMN needs_cleaning BOOLEAN NOT NULL DEFAULT false;
+CREATE TABLE cleaning_tasks (
+	id BIGSERIAL PRIMARY KEY,
+	registration TEXT NOT NULL,
+	assignee TEXT NOT NULL,
+	returned_at TIMESTAMPTZ NOT NULL,
+	cleaned_at TIMESTAMPTZ,
+	cleaned_by TEXT NOT NULL
+);
+CREATE INDEX cleaning_tasks_registration ON cleaning_tasks (registration);
@@ -0,0 +1 @@
+ALTER TABLE cars DROP COLUMN deleted_at;
@@ -0,0 +1 @@
+ALTER TABLE cars ADD COLUMN deleted_at TIMESTAMPTZ;
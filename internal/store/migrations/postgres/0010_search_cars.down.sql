@@ -0,0 +1,2 @@
+-- The extension may be used by others, so it is left installed.
+DROP INDEX cars_search;
@@ -0,0 +1,4 @@
+-- A trigram index lets ILIKE '%term%' and similarity() use an index.
+-- pg_trgm is a trusted extension, so the database owner can create it.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX cars_search ON cars USING gin ((model || ' ' || registration) gin_trgm_ops);
@@ -0,0 +1,11 @@
+DROP INDEX cars_search;
+ALTER TABLE cars
+	DROP COLUMN seats,
+	DROP COLUMN fuel,
+	DROP COLUMN transmission,
+	DROP COLUMN category,
+	DROP COLUMN vin,
+	DROP COLUMN color,
+	DROP COLUMN year,
+	DROP COLUMN make;
+CREATE INDEX cars_search ON cars USING gin ((model || ' ' || registration) gin_trgm_ops);
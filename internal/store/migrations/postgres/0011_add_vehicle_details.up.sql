@@ -0,0 +1,14 @@
+-- Existing cars get empty details, which mean unknown.
+ALTER TABLE cars
+	ADD COLUMN make TEXT NOT NULL DEFAULT '',
+	ADD COLUMN year INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN color TEXT NOT NULL DEFAULT '',
+	ADD COLUMN vin TEXT NOT NULL DEFAULT '',
+	ADD COLUMN category TEXT NOT NULL DEFAULT '',
+	ADD COLUMN transmission TEXT NOT NULL DEFAULT '',
+	ADD COLUMN fuel TEXT NOT NULL DEFAULT '',
+	ADD COLUMN seats INTEGER NOT NULL DEFAULT 0;
+
+-- Search the make and color as well.
+DROP INDEX cars_search;
+CREATE INDEX cars_search ON cars USING gin ((make || ' ' || model || ' ' || color || ' ' || registration) gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX cars_category;
+DROP TABLE categories;
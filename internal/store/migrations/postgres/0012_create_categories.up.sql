@@ -0,0 +1,12 @@
+-- The categories accepted for cars so far become the initial rows, so
+-- every car's category exists.
+CREATE TABLE categories (
+	name TEXT PRIMARY KEY,
+	description TEXT NOT NULL
+);
+INSERT INTO categories (name, description) VALUES
+	('economy', 'Small, fuel-efficient cars'),
+	('suv', 'Sport utility vehicles'),
+	('luxury', 'Premium and executive cars'),
+	('van', 'Vans and people carriers');
+CREATE INDEX cars_category ON cars (category);
@@ -0,0 +1,3 @@
+ALTER TABLE cars DROP CONSTRAINT cars_category_fkey;
+UPDATE cars SET category = '' WHERE category IS NULL;
+ALTER TABLE cars ALTER COLUMN category SET DEFAULT '', ALTER COLUMN category SET NOT NULL;
@@ -0,0 +1,5 @@
+-- Cars without a category hold NULL rather than '', so that every other
+-- value can reference a category row.
+ALTER TABLE cars ALTER COLUMN category DROP NOT NULL, ALTER COLUMN category DROP DEFAULT;
+UPDATE cars SET category = NULL WHERE category = '';
+ALTER TABLE cars ADD CONSTRAINT cars_category_fkey FOREIGN KEY (category) REFERENCES categories (name);
@@ -0,0 +1,2 @@
+DROP INDEX cars_search;
+CREATE INDEX cars_search ON cars USING gin ((make || ' ' || model || ' ' || color || ' ' || registration) gin_trgm_ops);
@@ -0,0 +1,3 @@
+-- A NULL model made the whole document NULL, hiding the car from search.
+DROP INDEX cars_search;
+CREATE INDEX cars_search ON cars USING gin ((COALESCE(make, '') || ' ' || COALESCE(model, '') || ' ' || COALESCE(color, '') || ' ' || COALESCE(registration, '')) gin_trgm_ops);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cars (
+	model TEXT,
+	registration TEXT PRIMARY KEY,
+	mileage INTEGER,
+	rented BOOLEAN
+);
@@ -0,0 +1,8 @@
+CREATE TABLE deprecated_usage (
+	surface TEXT NOT NULL,
+	client TEXT NOT NULL,
+	requests INTEGER NOT NULL,
+	first_seen DATETIME NOT NULL,
+	last_seen DATETIME NOT NULL,
+	PRIMARY KEY (surface, client)
+);
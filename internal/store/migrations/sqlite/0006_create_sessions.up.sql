@@ -0,0 +1,11 @@
+CREATE TABLE sessions (
+	id TEXT PRIMARY KEY,
+	username TEXT NOT NULL,
+	role TEXT NOT NULL,
+	refresh_token_hash TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	last_used_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL,
+	revoked_at DATETIME
+);
+CREATE INDEX sessions_username ON sessions (username);
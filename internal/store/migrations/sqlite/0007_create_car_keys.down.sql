@@ -0,0 +1,2 @@
+DROP TABLE key_handovers;
+DROP TABLE car_keys;
@@ -0,0 +1,2 @@
+DROP TABLE cleaning_tasks;
+ALTER TABLE cars DROP COLUMN needs_cleaning;
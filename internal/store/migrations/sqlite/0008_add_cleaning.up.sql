@@ -0,0 +1,10 @@
+ALTER TABLE cars ADD COLUMN needs_cleaning BOOLEAN NOT NULL DEFAULT false;
+CREATE TABLE cleaning_tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	registration TEXT NOT NULL,
+	assignee TEXT NOT NULL,
+	returned_at DATETIME NOT NULL,
+	cleaned_at DATETIME,
+	cleaned_by TEXT NOT NULL
+);
+CREATE INDEX cleaning_tasks_registration ON cleaning_tasks (registration);
@@ -0,0 +1,4 @@
+DROP TRIGGER cars_search_update;
+DROP TRIGGER cars_search_delete;
+DROP TRIGGER cars_search_insert;
+DROP TABLE cars_search;
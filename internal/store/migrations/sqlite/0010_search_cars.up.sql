@@ -0,0 +1,14 @@
+-- An external-content FTS5 index over the cars table, kept in step by
+-- triggers.
+CREATE VIRTUAL TABLE cars_search USING fts5(registration, model, content='cars');
+INSERT INTO cars_search (cars_search) VALUES ('rebuild');
+CREATE TRIGGER cars_search_insert AFTER INSERT ON cars BEGIN
+	INSERT INTO cars_search (rowid, registration, model) VALUES (new.rowid, new.registration, new.model);
+END;
+CREATE TRIGGER cars_search_delete AFTER DELETE ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model) VALUES ('delete', old.rowid, old.registration, old.model);
+END;
+CREATE TRIGGER cars_search_update AFTER UPDATE OF registration, model ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model) VALUES ('delete', old.rowid, old.registration, old.model);
+	INSERT INTO cars_search (rowid, registration, model) VALUES (new.rowid, new.registration, new.model);
+END;
@@ -0,0 +1,27 @@
+-- The triggers refer to the new columns, so the index goes first.
+DROP TRIGGER cars_search_update;
+DROP TRIGGER cars_search_delete;
+DROP TRIGGER cars_search_insert;
+DROP TABLE cars_search;
+
+ALTER TABLE cars DROP COLUMN seats;
+ALTER TABLE cars DROP COLUMN fuel;
+ALTER TABLE cars DROP COLUMN transmission;
+ALTER TABLE cars DROP COLUMN category;
+ALTER TABLE cars DROP COLUMN vin;
+ALTER TABLE cars DROP COLUMN color;
+ALTER TABLE cars DROP COLUMN year;
+ALTER TABLE cars DROP COLUMN make;
+
+CREATE VIRTUAL TABLE cars_search USING fts5(registration, model, content='cars');
+INSERT INTO cars_search (cars_search) VALUES ('rebuild');
+CREATE TRIGGER cars_search_insert AFTER INSERT ON cars BEGIN
+	INSERT INTO cars_search (rowid, registration, model) VALUES (new.rowid, new.registration, new.model);
+END;
+CREATE TRIGGER cars_search_delete AFTER DELETE ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model) VALUES ('delete', old.rowid, old.registration, old.model);
+END;
+CREATE TRIGGER cars_search_update AFTER UPDATE OF registration, model ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model) VALUES ('delete', old.rowid, old.registration, old.model);
+	INSERT INTO cars_search (rowid, registration, model) VALUES (new.rowid, new.registration, new.model);
+END;
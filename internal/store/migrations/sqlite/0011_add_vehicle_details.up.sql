@@ -0,0 +1,27 @@
+-- Existing cars get empty details, which mean unknown.
+ALTER TABLE cars ADD COLUMN make TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN year INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE cars ADD COLUMN color TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN vin TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN transmission TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN fuel TEXT NOT NULL DEFAULT '';
+ALTER TABLE cars ADD COLUMN seats INTEGER NOT NULL DEFAULT 0;
+
+-- Search the make and color as well.
+DROP TRIGGER cars_search_update;
+DROP TRIGGER cars_search_delete;
+DROP TRIGGER cars_search_insert;
+DROP TABLE cars_search;
+CREATE VIRTUAL TABLE cars_search USING fts5(registration, model, make, color, content='cars');
+INSERT INTO cars_search (cars_search) VALUES ('rebuild');
+CREATE TRIGGER cars_search_insert AFTER INSERT ON cars BEGIN
+	INSERT INTO cars_search (rowid, registration, model, make, color) VALUES (new.rowid, new.registration, new.model, new.make, new.color);
+END;
+CREATE TRIGGER cars_search_delete AFTER DELETE ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model, make, color) VALUES ('delete', old.rowid, old.registration, old.model, old.make, old.color);
+END;
+CREATE TRIGGER cars_search_update AFTER UPDATE OF registration, model, make, color ON cars BEGIN
+	INSERT INTO cars_search (cars_search, rowid, registration, model, make, color) VALUES ('delete', old.rowid, old.registration, old.model, old.make, old.color);
+	INSERT INTO cars_search (rowid, registration, model, make, color) VALUES (new.rowid, new.registration, new.model, new.make, new.color);
+END;
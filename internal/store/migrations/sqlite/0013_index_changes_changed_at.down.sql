@@ -0,0 +1 @@
+DROP INDEX changes_changed_at;
@@ -0,0 +1,6 @@
+DROP INDEX cars_category;
+ALTER TABLE cars RENAME COLUMN category TO category_name;
+ALTER TABLE cars ADD COLUMN category TEXT NOT NULL DEFAULT '';
+UPDATE cars SET category = COALESCE(category_name, '');
+ALTER TABLE cars DROP COLUMN category_name;
+CREATE INDEX cars_category ON cars (category);
@@ -0,0 +1,9 @@
+-- Cars without a category hold NULL rather than '', so that every other
+-- value can reference a category row. SQLite cannot add a constraint to a
+-- column, so the column is replaced.
+DROP INDEX cars_category;
+ALTER TABLE cars RENAME COLUMN category TO category_name;
+ALTER TABLE cars ADD COLUMN category TEXT REFERENCES categories (name);
+UPDATE cars SET category = NULLIF(category_name, '');
+ALTER TABLE cars DROP COLUMN category_name;
+CREATE INDEX cars_category ON cars (category);
@@ -0,0 +1,3 @@
+-- Only the Postgres search index needs changing; FTS5 indexes a NULL
+-- column as empty.
+SELECT 1;
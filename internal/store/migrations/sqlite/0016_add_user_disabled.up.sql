@@ -0,0 +1,3 @@
+-- Disabled users cannot log in or refresh; disabling also revokes their
+-- sessions.
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- Every write to a car increments its version, which the API sends as
+-- the car's ETag for conditional requests.
+ALTER TABLE cars ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms bounds the size of a search query.
+const maxSearchTerms = 8
+
+// searchTerms splits a search query into lowercase words of letters and
+// digits, the way the SQLite tokenizer splits the searched columns. Every
+// dialect then keeps the cars in which each term starts a word.
+func searchTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// searchFTS5 matches every term as a prefix in the FTS5 index and ranks by
+// BM25, weighting registration hits above the other columns.
+func searchFTS5(terms []string, limit int) (string, []interface{}) {
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		// Terms hold only letters and digits, so quoting them is enough
+		// to keep FTS5 query syntax out.
+		match[i] = `"` + term + `"*`
+	}
+	return `SELECT ` + carColumns + ` FROM cars
+		JOIN (SELECT rowid AS id, bm25(cars_search, 10.0, 1.0, 1.0, 1.0) AS score FROM cars_search WHERE cars_search MATCH ?) AS hits
+		ON cars.rowid = hits.id
+		WHERE deleted_at IS NULL
+		ORDER BY hits.score, registration LIMIT ?`, []interface{}{strings.Join(match, " "), limit}
+}
+
+// trigramDocument is the expression of the Postgres trigram index. The
+// model may be NULL, which would make the whole document NULL.
+const trigramDocument = "(COALESCE(make, '') || ' ' || COALESCE(model, '') || ' ' || COALESCE(color, '') || ' ' || COALESCE(registration, ''))"
+
+// searchTrigram matches every term with a case-insensitive regular
+// expression, which the trigram index serves, and ranks by trigram
+// similarity to the whole query.
+func searchTrigram(terms []string, limit int) (string, []interface{}) {
+	return searchWords(trigramDocument+" ~* ?", "similarity("+trigramDocument+", ?)", terms, limit)
+}
+
+// searchFulltext matches every term with a regular expression and ranks
+// by FULLTEXT relevance, which ignores words shorter than the server's
+// minimum token size. CONCAT_WS skips NULL columns. The cars_search index
+// only serves the MATCH ranking, which needs it to cover exactly the
+// matched columns; the comment of migration 0010 predates the REGEXP
+// filter and still speaks of LIKE, but applied migrations are not edited.
+func searchFulltext(terms []string, limit int) (string, []interface{}) {
+	return searchWords("LOWER(CONCAT_WS(' ', make, model, color, registration)) REGEXP ?", "MATCH (make, model, color, registration) AGAINST (?)", terms, limit)
+}
+
+// wordPrefix returns the regular expression matching term at the start of
+// a word, as an FTS5 prefix query does. Terms hold only letters and
+// digits, so they need no escaping.
+func wordPrefix(term string) string {
+	return "(^|[^[:alnum:]])" + term
+}
+
+// searchWords builds a search that keeps the cars for which match holds
+// with the wordPrefix of every term, best rank for the whole query first.
+func searchWords(match, rank string, terms []string, limit int) (string, []interface{}) {
+	conds := make([]string, len(terms))
+	args := make([]interface{}, 0, len(terms)+2)
+	for i, term := range terms {
+		conds[i] = match
+		args = append(args, wordPrefix(term))
+	}
+	args = append(args, strings.Join(terms, " "), limit)
+	return `SELECT ` + carColumns + ` FROM cars
+		WHERE deleted_at IS NULL AND ` + strings.Join(conds, " AND ") + `
+		ORDER BY ` + rank + ` DESC, registration LIMIT ?`, args
+}
+
+func (s *SQLRepository) Search(ctx context.Context, query string, limit int) ([]Car, error) {
+	ctx, done := s.startOperation(ctx, "search_cars")
+	defer done()
+
+	terms := searchTerms(query)
+	if len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	q, args := s.dialect.searchCars(terms, limit)
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cars []Car
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return nil, err
+		}
+		cars = append(cars, car)
+	}
+	return cars, rows.Err()
+}
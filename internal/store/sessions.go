@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const sessionColumns = "id, username, role, refresh_token_hash, created_at, last_used_at, expires_at, revoked_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row rowScanner) (Session, error) {
+	var (
+		s         Session
+		revokedAt sql.NullTime
+	)
+	err := row.Scan(&s.ID, &s.Username, &s.Role, &s.RefreshTokenHash, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt)
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, err
+}
+
+func (s *SQLRepository) CreateSession(ctx context.Context, session Session) error {
+	ctx, done := s.startOperation(ctx, "create_session")
+	defer done()
+
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind("INSERT INTO sessions ("+sessionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		session.ID, session.Username, session.Role, session.RefreshTokenHash,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt, session.RevokedAt)
+	return err
+}
+
+func (s *SQLRepository) GetSession(ctx context.Context, id string) (Session, error) {
+	ctx, done := s.startOperation(ctx, "get_session")
+	defer done()
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT "+sessionColumns+" FROM sessions WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrSessionNotFound
+	}
+	return session, err
+}
+
+func (s *SQLRepository) RotateRefreshToken(ctx context.Context, id, oldHash, newHash, role string, at time.Time) error {
+	ctx, done := s.startOperation(ctx, "rotate_refresh_token")
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(`UPDATE sessions SET refresh_token_hash = ?, role = ?, last_used_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND revoked_at IS NULL`), newHash, role, at, id, oldHash)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, ErrSessionNotFound)
+}
+
+func (s *SQLRepository) ListSessions(ctx context.Context, username string) ([]Session, error) {
+	ctx, done := s.startOperation(ctx, "list_sessions")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind("SELECT "+sessionColumns+` FROM sessions
+		WHERE username = ? AND revoked_at IS NULL ORDER BY created_at DESC, id`), username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *SQLRepository) RevokeSession(ctx context.Context, id string, at time.Time) error {
+	ctx, done := s.startOperation(ctx, "revoke_session")
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"), at, id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res, ErrSessionNotFound)
+}
+
+func (s *SQLRepository) RevokeUserSessions(ctx context.Context, username string, at time.Time) (int64, error) {
+	ctx, done := s.startOperation(ctx, "revoke_user_sessions")
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("UPDATE sessions SET revoked_at = ? WHERE username = ? AND revoked_at IS NULL"), at, username)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// requireRow returns notFound if res affected no rows.
+func requireRow(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
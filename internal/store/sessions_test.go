@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if _, err := repo.GetSession(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("get missing session: got %v, want %v", err, ErrSessionNotFound)
+	}
+	for i, id := range []string{"s1", "s2"} {
+		created := now.Add(time.Duration(i) * time.Minute)
+		err := repo.CreateSession(ctx, Session{
+			ID: id, Username: "alice", Role: "agent", RefreshTokenHash: "hash-" + id,
+			CreatedAt: created, LastUsedAt: created, ExpiresAt: created.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+
+	if err := repo.RotateRefreshToken(ctx, "s1", "stale", "new", "admin", now); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("rotate with stale hash: got %v, want %v", err, ErrSessionNotFound)
+	}
+	later := now.Add(5 * time.Minute)
+	if err := repo.RotateRefreshToken(ctx, "s1", "hash-s1", "new", "admin", later); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	got, err := repo.GetSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.RefreshTokenHash != "new" || got.Role != "admin" || !got.LastUsedAt.Equal(later) || got.RevokedAt != nil {
+		t.Fatalf("after rotation = %+v, want new hash, admin role, used at %v", got, later)
+	}
+
+	sessions, err := repo.ListSessions(ctx, "alice")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "s2" || sessions[1].ID != "s1" {
+		t.Fatalf("sessions = %+v, want s2 then s1", sessions)
+	}
+
+	if err := repo.RevokeSession(ctx, "s1", later); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := repo.RevokeSession(ctx, "s1", later); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoke twice: got %v, want %v", err, ErrSessionNotFound)
+	}
+	if got, err := repo.GetSession(ctx, "s1"); err != nil || got.RevokedAt == nil || !got.RevokedAt.Equal(later) {
+		t.Fatalf("revoked session = %+v, %v, want revoked at %v", got, err, later)
+	}
+	if err := repo.RotateRefreshToken(ctx, "s1", "new", "newer", "admin", later); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("rotate revoked session: got %v, want %v", err, ErrSessionNotFound)
+	}
+
+	n, err := repo.RevokeUserSessions(ctx, "alice", later)
+	if err != nil || n != 1 {
+		t.Fatalf("revoke user sessions = %d, %v, want 1", n, err)
+	}
+	if sessions, err := repo.ListSessions(ctx, "alice"); err != nil || len(sessions) != 0 {
+		t.Fatalf("sessions after revoking all = %+v, %v, want none", sessions, err)
+	}
+}
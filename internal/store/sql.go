@@ -0,0 +1,427 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// Config selects and tunes the database backend.
+type Config struct {
+	// Driver is the database/sql driver name: "sqlite", "postgres" or "mysql".
+	Driver string
+	// DSN is the driver-specific data source name.
+	DSN string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// SQLRepository is a CarRepository backed by a SQL database.
+type SQLRepository struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Open opens the database described by cfg and applies its pool settings.
+func Open(cfg Config) (*SQLRepository, error) {
+	d, err := lookupDialect(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := cfg.DSN
+	if d.prepareDSN != nil {
+		if dsn, err = d.prepareDSN(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	return &SQLRepository{db: db, dialect: d}, nil
+}
+
+// NewSQLRepository returns a repository using db, which must have been
+// opened with driver.
+func NewSQLRepository(db *sql.DB, driver string) (*SQLRepository, error) {
+	d, err := lookupDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLRepository{db: db, dialect: d}, nil
+}
+
+// Ping checks that the database is reachable.
+func (s *SQLRepository) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the underlying database.
+func (s *SQLRepository) Close() error {
+	return s.db.Close()
+}
+
+// Seed inserts the mock car unless it is already present.
+func (s *SQLRepository) Seed(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.seedCars)
+	return err
+}
+
+const carColumns = "model, registration, mileage, rented, needs_cleaning, deleted_at, version, " + vehicleColumns
+
+// vehicleColumns are the columns of the optional vehicle details, in the
+// order of vehicleValues.
+const vehicleColumns = "make, year, color, vin, category, transmission, fuel, seats"
+
+// vehicleValues stores a car without a category with a NULL category, as
+// any other value must name a categories row.
+func vehicleValues(car Car) []interface{} {
+	category := sql.NullString{String: car.Category, Valid: car.Category != ""}
+	return []interface{}{car.Make, car.Year, car.Color, car.VIN, category, car.Transmission, car.Fuel, car.Seats}
+}
+
+func scanCar(row rowScanner) (Car, error) {
+	var (
+		car       Car
+		deletedAt sql.NullTime
+		category  sql.NullString
+	)
+	err := row.Scan(&car.Model, &car.Registration, &car.Mileage, &car.Rented, &car.NeedsCleaning, &deletedAt, &car.Version,
+		&car.Make, &car.Year, &car.Color, &car.VIN, &category, &car.Transmission, &car.Fuel, &car.Seats)
+	if deletedAt.Valid {
+		car.DeletedAt = &deletedAt.Time
+	}
+	car.Category = category.String
+	return car, err
+}
+
+// where returns the WHERE clause selecting the cars of q, or "".
+func (q CarQuery) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	switch {
+	case q.Available && q.IncludeDeleted:
+		// Deleted cars are never rented, but may be waiting to be cleaned.
+		conds, args = append(conds, "((rented = ? AND needs_cleaning = ?) OR deleted_at IS NOT NULL)"), append(args, false, false)
+	case q.Available:
+		conds, args = append(conds, "rented = ? AND needs_cleaning = ? AND deleted_at IS NULL"), append(args, false, false)
+	case !q.IncludeDeleted:
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if q.After != "" {
+		conds, args = append(conds, "registration > ?"), append(args, q.After)
+	}
+	if q.Rented != nil {
+		conds, args = append(conds, "rented = ?"), append(args, *q.Rented)
+	}
+	if q.Model != "" {
+		// '!' rather than the usual backslash, which MySQL would read as
+		// an escape in the string literal itself.
+		pattern := likeEscaper.Replace(strings.ToLower(q.Model))
+		conds, args = append(conds, "LOWER(model) LIKE ? ESCAPE '!'"), append(args, "%"+pattern+"%")
+	}
+	if q.MinMileage != nil {
+		conds, args = append(conds, "mileage >= ?"), append(args, *q.MinMileage)
+	}
+	if q.MaxMileage != nil {
+		conds, args = append(conds, "mileage <= ?"), append(args, *q.MaxMileage)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// orderBy returns the ORDER BY clause of q. Only known columns are written
+// into the query.
+func (q CarQuery) orderBy() string {
+	dir := ""
+	if q.Sort.Desc {
+		dir = " DESC"
+	}
+	switch q.Sort.Field {
+	case SortModel, SortMileage:
+		return " ORDER BY " + q.Sort.Field + dir + ", registration"
+	default:
+		return " ORDER BY registration" + dir
+	}
+}
+
+func (s *SQLRepository) List(ctx context.Context, q CarQuery) ([]Car, error) {
+	ctx, done := s.startOperation(ctx, "list_cars")
+	defer done()
+
+	where, args := q.where()
+	query := "SELECT " + carColumns + " FROM cars" + where + q.orderBy()
+	if q.Limit > 0 || q.Offset > 0 {
+		// Not every dialect takes OFFSET without LIMIT.
+		limit := int64(math.MaxInt64)
+		if q.Limit > 0 {
+			limit = int64(q.Limit)
+		}
+		query, args = query+" LIMIT ? OFFSET ?", append(args, limit, q.Offset)
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cars []Car
+	for rows.Next() {
+		car, err := scanCar(rows)
+		if err != nil {
+			return nil, err
+		}
+		cars = append(cars, car)
+	}
+	return cars, rows.Err()
+}
+
+func (s *SQLRepository) Count(ctx context.Context, q CarQuery) (int, error) {
+	ctx, done := s.startOperation(ctx, "count_cars")
+	defer done()
+
+	q.After = ""
+	where, args := q.where()
+	var n int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT COUNT(*) FROM cars"+where), args...).Scan(&n)
+	return n, err
+}
+
+func (s *SQLRepository) Get(ctx context.Context, registration string) (Car, error) {
+	ctx, done := s.startOperation(ctx, "get_car")
+	defer done()
+
+	car, err := scanCar(s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT "+carColumns+" FROM cars WHERE registration = ?"), registration))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Car{}, ErrCarNotFound
+	}
+	return car, err
+}
+
+func (s *SQLRepository) CountRented(ctx context.Context) (int, error) {
+	ctx, done := s.startOperation(ctx, "count_rented")
+	defer done()
+
+	var n int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT COUNT(*) FROM cars WHERE rented = ?"), true).Scan(&n)
+	return n, err
+}
+
+func (s *SQLRepository) Add(ctx context.Context, car Car) error {
+	ctx, done := s.startOperation(ctx, "add_car")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.requireCategory(ctx, tx, car.Category); err != nil {
+			return err
+		}
+		args := append([]interface{}{car.Model, car.Registration, car.Mileage, car.Rented}, vehicleValues(car)...)
+		_, err := tx.ExecContext(ctx, s.dialect.rebind(`INSERT INTO cars (model, registration, mileage, rented, `+vehicleColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`), args...)
+		if isForeignKeyViolation(err) {
+			return ErrCategoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCar, car.Registration, OpCreate)
+	})
+}
+
+func (s *SQLRepository) Update(ctx context.Context, car Car) error {
+	ctx, done := s.startOperation(ctx, "update_car")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.requireCategory(ctx, tx, car.Category); err != nil {
+			return err
+		}
+		query := `UPDATE cars SET model = ?, mileage = ?, version = version + 1,
+			make = ?, year = ?, color = ?, vin = ?, category = ?, transmission = ?, fuel = ?, seats = ?
+			WHERE registration = ? AND deleted_at IS NULL`
+		args := append(append([]interface{}{car.Model, car.Mileage}, vehicleValues(car)...), car.Registration)
+		if car.Version != 0 {
+			query, args = query+" AND version = ?", append(args, car.Version)
+		}
+		res, err := tx.ExecContext(ctx, s.dialect.rebind(query), args...)
+		if isForeignKeyViolation(err) {
+			return ErrCategoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Only the version can fail to match a car that exists.
+		if err := s.checkUpdated(ctx, tx, res, car.Registration, ErrCarModified); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCar, car.Registration, OpUpdate)
+	})
+}
+
+func (s *SQLRepository) MarkRented(ctx context.Context, registration string) error {
+	ctx, done := s.startOperation(ctx, "mark_rented")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// The rented = false guard makes the check-and-set atomic, so two
+		// concurrent requests cannot both rent the same car.
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cars SET rented = ?, version = version + 1 WHERE registration = ? AND rented = ? AND needs_cleaning = ? AND deleted_at IS NULL"),
+			true, registration, false, false)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return s.rentRefusal(ctx, tx, registration)
+		}
+		return s.recordChange(ctx, tx, EntityCar, registration, OpUpdate)
+	})
+}
+
+func (s *SQLRepository) MarkReturned(ctx context.Context, registration string, drivenMileage int) error {
+	ctx, done := s.startOperation(ctx, "mark_returned")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cars SET rented = ?, mileage = mileage + ?, version = version + 1 WHERE registration = ? AND rented = ?"),
+			false, drivenMileage, registration, true)
+		if err != nil {
+			return err
+		}
+		if err := s.checkUpdated(ctx, tx, res, registration, ErrCarNotRented); err != nil {
+			return err
+		}
+		if err := s.openCleaningTask(ctx, tx, registration, time.Now().UTC()); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCar, registration, OpUpdate)
+	})
+}
+
+func (s *SQLRepository) Delete(ctx context.Context, registration string, version int64, at time.Time) error {
+	ctx, done := s.startOperation(ctx, "delete_car")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		query := "UPDATE cars SET deleted_at = ?, version = version + 1 WHERE registration = ? AND rented = ? AND deleted_at IS NULL"
+		args := []interface{}{at, registration, false}
+		if version != 0 {
+			query, args = query+" AND version = ?", append(args, version)
+		}
+		res, err := tx.ExecContext(ctx, s.dialect.rebind(query), args...)
+		if err != nil {
+			return err
+		}
+		if err := s.checkUpdated(ctx, tx, res, registration, ErrCarAlreadyRented); err != nil {
+			return s.versionRefusal(ctx, tx, registration, version, err)
+		}
+		return s.recordChange(ctx, tx, EntityCar, registration, OpDelete)
+	})
+}
+
+func (s *SQLRepository) Restore(ctx context.Context, registration string) error {
+	ctx, done := s.startOperation(ctx, "restore_car")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE cars SET deleted_at = NULL, version = version + 1 WHERE registration = ? AND deleted_at IS NOT NULL"), registration)
+		if err != nil {
+			return err
+		}
+		// A deleted car would have matched, so any car checkUpdated finds
+		// is not deleted.
+		if err := s.checkUpdated(ctx, tx, res, registration, ErrCarNotDeleted); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, EntityCar, registration, OpUpdate)
+	})
+}
+
+// versionRefusal returns ErrCarModified in place of err if a conditional
+// write of the car at version failed because the car has another version.
+func (s *SQLRepository) versionRefusal(ctx context.Context, tx *sql.Tx, registration string, version int64, err error) error {
+	if version == 0 || errors.Is(err, ErrCarNotFound) {
+		return err
+	}
+	var current int64
+	if qerr := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT version FROM cars WHERE registration = ?"), registration).Scan(&current); qerr != nil {
+		return qerr
+	}
+	if current != version {
+		return ErrCarModified
+	}
+	return err
+}
+
+// rentRefusal explains why MarkRented matched no rows.
+func (s *SQLRepository) rentRefusal(ctx context.Context, tx *sql.Tx, registration string) error {
+	var (
+		rented, needsCleaning bool
+		deletedAt             sql.NullTime
+	)
+	err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT rented, needs_cleaning, deleted_at FROM cars WHERE registration = ?"), registration).
+		Scan(&rented, &needsCleaning, &deletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), err == nil && deletedAt.Valid:
+		return ErrCarNotFound
+	case err != nil:
+		return err
+	case rented:
+		return ErrCarAlreadyRented
+	default:
+		return ErrCarNeedsCleaning
+	}
+}
+
+// checkUpdated turns a conditional update that matched no rows into either
+// ErrCarNotFound or stateErr, depending on whether the car exists and is
+// not deleted.
+func (s *SQLRepository) checkUpdated(ctx context.Context, tx *sql.Tx, res sql.Result, registration string, stateErr error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM cars WHERE registration = ? AND deleted_at IS NULL)"), registration).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCarNotFound
+	}
+	return stateErr
+}
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func (s *SQLRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
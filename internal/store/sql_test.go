@@ -0,0 +1,438 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRepository(t *testing.T) *SQLRepository {
+	t.Helper()
+
+	dsn, err := prepareSQLiteDSN(":memory:")
+	if err != nil {
+		t.Fatalf("prepare DSN: %v", err)
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Each connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewSQLRepository(db, "sqlite")
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return repo
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	for i := 0; i < 2; i++ {
+		if err := repo.Seed(ctx); err != nil {
+			t.Fatalf("seed #%d: %v", i+1, err)
+		}
+	}
+	cars, err := repo.List(ctx, CarQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(cars) != 1 {
+		t.Fatalf("got %d cars, want 1", len(cars))
+	}
+}
+
+func TestMarkRentedAndReturned(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if err := repo.MarkReturned(ctx, "DEF456", 10); !errors.Is(err, ErrCarNotRented) {
+		t.Fatalf("return available car: got %v, want %v", err, ErrCarNotRented)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); !errors.Is(err, ErrCarAlreadyRented) {
+		t.Fatalf("rent rented car: got %v, want %v", err, ErrCarAlreadyRented)
+	}
+	if err := repo.MarkReturned(ctx, "DEF456", 100); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+
+	cars, err := repo.List(ctx, CarQuery{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(cars) != 1 || cars[0].Rented || cars[0].Mileage != 3300 {
+		t.Fatalf("got %+v, want DEF456 available with mileage 3300", cars)
+	}
+	if car, err := repo.Get(ctx, "DEF456"); err != nil || car != cars[0] {
+		t.Fatalf("get: got %+v, %v, want %+v", car, err, cars[0])
+	}
+}
+
+func TestUpdateCar(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200, Rented: true}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if err := repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "DEF456", Mileage: 100}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	// Updating to the same values is not an error on any database.
+	if err := repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "DEF456", Mileage: 100}); err != nil {
+		t.Fatalf("update unchanged: %v", err)
+	}
+	car, err := repo.Get(ctx, "DEF456")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if want := (Car{Model: "Honda Jazz", Registration: "DEF456", Mileage: 100, Rented: true, Version: 3}); car != want {
+		t.Fatalf("got %+v, want %+v", car, want)
+	}
+	if err := repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "NOPE"}); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("update unknown car: got %v, want %v", err, ErrCarNotFound)
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if err := repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "DEF456", Version: 1}); err != nil {
+		t.Fatalf("update at current version: %v", err)
+	}
+	if err := repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "DEF456", Version: 1}); !errors.Is(err, ErrCarModified) {
+		t.Fatalf("update at old version: got %v, want %v", err, ErrCarModified)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	if err := repo.Delete(ctx, "DEF456", 2, time.Now()); !errors.Is(err, ErrCarModified) {
+		t.Fatalf("delete at old version: got %v, want %v", err, ErrCarModified)
+	}
+	if err := repo.Delete(ctx, "DEF456", 3, time.Now()); !errors.Is(err, ErrCarAlreadyRented) {
+		t.Fatalf("delete rented car at current version: got %v, want %v", err, ErrCarAlreadyRented)
+	}
+	if err := repo.MarkReturned(ctx, "DEF456", 0); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	car, err := repo.Get(ctx, "DEF456")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if err := repo.Delete(ctx, "DEF456", car.Version, time.Now()); err != nil {
+		t.Fatalf("delete at current version: %v", err)
+	}
+	if err := repo.Delete(ctx, "DEF456", car.Version+1, time.Now()); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("delete deleted car: got %v, want %v", err, ErrCarNotFound)
+	}
+}
+
+func TestMarkUnknownCar(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	if err := repo.MarkRented(ctx, "NOPE"); !errors.Is(err, ErrCarNotFound) {
+		t.Errorf("rent: got %v, want %v", err, ErrCarNotFound)
+	}
+	if err := repo.MarkReturned(ctx, "NOPE", 0); !errors.Is(err, ErrCarNotFound) {
+		t.Errorf("return: got %v, want %v", err, ErrCarNotFound)
+	}
+	if _, err := repo.Get(ctx, "NOPE"); !errors.Is(err, ErrCarNotFound) {
+		t.Errorf("get: got %v, want %v", err, ErrCarNotFound)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	repo := newTestRepository(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := repo.List(ctx, CarQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("list: got %v, want %v", err, context.Canceled)
+	}
+	if err := repo.Add(ctx, Car{Registration: "DEF456"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("add: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, car := range []Car{
+		{Model: "Honda Civic", Registration: "DEF456", Mileage: 3200},
+		{Model: "Tesla M3", Registration: "BTS812", Mileage: 6003},
+	} {
+		if err := repo.Add(ctx, car); err != nil {
+			t.Fatalf("add %s: %v", car.Registration, err)
+		}
+	}
+	if err := repo.MarkRented(ctx, "BTS812"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	if err := repo.Delete(ctx, "BTS812", 0, time.Now()); !errors.Is(err, ErrCarAlreadyRented) {
+		t.Fatalf("delete rented car: got %v, want %v", err, ErrCarAlreadyRented)
+	}
+	if err := repo.Restore(ctx, "DEF456"); !errors.Is(err, ErrCarNotDeleted) {
+		t.Fatalf("restore car that is not deleted: got %v, want %v", err, ErrCarNotDeleted)
+	}
+
+	at := time.Now().UTC().Truncate(time.Second)
+	if err := repo.Delete(ctx, "DEF456", 0, at); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := repo.Delete(ctx, "DEF456", 0, at); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("delete twice: got %v, want %v", err, ErrCarNotFound)
+	}
+	if cars, err := repo.List(ctx, CarQuery{}); err != nil || len(cars) != 1 || cars[0].Registration != "BTS812" {
+		t.Fatalf("list = %+v, %v, want only BTS812", cars, err)
+	}
+	if cars, err := repo.List(ctx, CarQuery{IncludeDeleted: true}); err != nil || len(cars) != 2 {
+		t.Fatalf("list with deleted = %+v, %v, want both cars", cars, err)
+	}
+	if car, err := repo.Get(ctx, "DEF456"); err != nil || car.DeletedAt == nil || !car.DeletedAt.Equal(at) {
+		t.Fatalf("get deleted car = %+v, %v, want deleted at %v", car, err, at)
+	}
+
+	for name, op := range map[string]func() error{
+		"rent":   func() error { return repo.MarkRented(ctx, "DEF456") },
+		"update": func() error { return repo.Update(ctx, Car{Model: "Honda Jazz", Registration: "DEF456"}) },
+		"add key": func() error {
+			return repo.AddKey(ctx, CarKey{ID: "k1", Registration: "DEF456", Kind: KeyPrimary}, "admin")
+		},
+	} {
+		if err := op(); !errors.Is(err, ErrCarNotFound) {
+			t.Errorf("%s deleted car: got %v, want %v", name, err, ErrCarNotFound)
+		}
+	}
+
+	if err := repo.Restore(ctx, "DEF456"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if err := repo.Restore(ctx, "NOPE"); !errors.Is(err, ErrCarNotFound) {
+		t.Fatalf("restore unknown car: got %v, want %v", err, ErrCarNotFound)
+	}
+	if car, err := repo.Get(ctx, "DEF456"); err != nil || car.DeletedAt != nil || car.Mileage != 3200 {
+		t.Fatalf("restored car = %+v, %v, want DEF456 as before", car, err)
+	}
+	if err := repo.MarkRented(ctx, "DEF456"); err != nil {
+		t.Fatalf("rent restored car: %v", err)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, car := range []Car{
+		{Model: "Tesla Model 3", Registration: "AAA111", Mileage: 42000},
+		{Model: "Honda Civic", Registration: "BBB222", Mileage: 3200},
+		{Model: "TESLA Model Y", Registration: "CCC333", Mileage: 3200},
+		{Model: "100% Electric", Registration: "DDD444", Mileage: 90000},
+	} {
+		if err := repo.Add(ctx, car); err != nil {
+			t.Fatalf("add %s: %v", car.Registration, err)
+		}
+	}
+	if err := repo.MarkRented(ctx, "AAA111"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	yes, no := true, false
+	zero, limit := 0, 50000
+
+	tests := []struct {
+		q    CarQuery
+		want string
+	}{
+		{CarQuery{Rented: &yes}, "AAA111"},
+		{CarQuery{Rented: &no}, "BBB222 CCC333 DDD444"},
+		{CarQuery{Model: "tesla"}, "AAA111 CCC333"},
+		{CarQuery{Model: "0%"}, "DDD444"},
+		{CarQuery{Model: "_"}, ""},
+		{CarQuery{MinMileage: &zero, MaxMileage: &limit}, "AAA111 BBB222 CCC333"},
+		{CarQuery{Available: true, Model: "Tesla", MaxMileage: &limit}, "CCC333"},
+		{CarQuery{Sort: CarSort{Field: SortMileage, Desc: true}}, "DDD444 AAA111 BBB222 CCC333"},
+		{CarQuery{Sort: CarSort{Field: SortMileage}, Limit: 2}, "BBB222 CCC333"},
+		{CarQuery{Sort: CarSort{Field: SortRegistration, Desc: true}}, "DDD444 CCC333 BBB222 AAA111"},
+	}
+	for _, tt := range tests {
+		cars, err := repo.List(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("list %+v: %v", tt.q, err)
+		}
+		var got []string
+		for _, car := range cars {
+			got = append(got, car.Registration)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("list %+v = %v, want %s", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, car := range []Car{
+		{Model: "Toyota Corolla", Registration: "AB-12-CD"},
+		{Model: "Corolla Cross", Registration: "XYZ987"},
+	} {
+		if err := repo.Add(ctx, car); err != nil {
+			t.Fatalf("add %s: %v", car.Registration, err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"toyota", "AB-12-CD"},
+		{"xyz", "XYZ987"},
+		{"ab-12", "AB-12-CD"},
+		{"CROSS coro", "XYZ987"},
+		{"orolla", ""},
+		{"corolla van", ""},
+		{"--", ""},
+	}
+	for _, tt := range tests {
+		cars, err := repo.Search(ctx, tt.query, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		var got []string
+		for _, car := range cars {
+			got = append(got, car.Registration)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("search %q = %v, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	// The index follows updates.
+	if err := repo.Update(ctx, Car{Model: "Yaris", Registration: "AB-12-CD"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if cars, err := repo.Search(ctx, "yaris", 10); err != nil || len(cars) != 1 {
+		t.Errorf("search yaris = %v, %v; want the updated car", cars, err)
+	}
+}
+
+func TestVehicleDetails(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	car := Car{Model: "Corolla", Registration: "ABC123", Mileage: 10, Make: "Toyota", Year: 2022, Color: "Blue",
+		VIN: "1HGCM82633A004352", Category: "economy", Transmission: "automatic", Fuel: "hybrid", Seats: 5}
+	if err := repo.Add(ctx, car); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	car.Version = 1
+	if got, err := repo.Get(ctx, "ABC123"); err != nil || got != car {
+		t.Fatalf("get = %+v, %v; want %+v", got, err, car)
+	}
+	if cars, err := repo.Search(ctx, "blue toyota", 10); err != nil || len(cars) != 1 {
+		t.Errorf("search blue toyota = %v, %v; want the car", cars, err)
+	}
+
+	car.Color, car.Seats = "", 0
+	if err := repo.Update(ctx, car); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	car.Version++
+	if got, err := repo.Get(ctx, "ABC123"); err != nil || got != car {
+		t.Errorf("get after update = %+v, %v; want %+v", got, err, car)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	if got := searchTerms(`Blue "Corolla"* AB-12`); strings.Join(got, " ") != "blue corolla ab 12" {
+		t.Errorf("terms = %q", got)
+	}
+	if got := searchTerms(strings.Repeat("a ", 20)); len(got) != maxSearchTerms {
+		t.Errorf("got %d terms, want %d", len(got), maxSearchTerms)
+	}
+}
+
+// TestWordPrefix checks the pattern Postgres and MySQL search with against
+// the SQLite prefix matches of TestSearch.
+func TestWordPrefix(t *testing.T) {
+	const document = "toyota corolla ab-12-cd"
+	for term, want := range map[string]bool{
+		"toyota": true,
+		"coro":   true,
+		"orolla": false,
+		"12":     true,
+		"cd":     true,
+		"2":      false,
+	} {
+		if got := regexp.MustCompile(wordPrefix(term)).MatchString(document); got != want {
+			t.Errorf("%q matches %q = %v, want %v", wordPrefix(term), document, got, want)
+		}
+	}
+}
+
+func TestListQuery(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, reg := range []string{"EEE555", "AAA111", "DDD444", "BBB222", "CCC333"} {
+		if err := repo.Add(ctx, Car{Model: "Honda Civic", Registration: reg}); err != nil {
+			t.Fatalf("add %s: %v", reg, err)
+		}
+	}
+	if err := repo.MarkRented(ctx, "BBB222"); err != nil {
+		t.Fatalf("rent: %v", err)
+	}
+	if err := repo.Delete(ctx, "DDD444", 0, time.Now()); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	tests := []struct {
+		q    CarQuery
+		want string
+		n    int
+	}{
+		{CarQuery{}, "AAA111 BBB222 CCC333 EEE555", 4},
+		{CarQuery{Available: true}, "AAA111 CCC333 EEE555", 3},
+		{CarQuery{Available: true, IncludeDeleted: true}, "AAA111 CCC333 DDD444 EEE555", 4},
+		{CarQuery{IncludeDeleted: true, Limit: 2}, "AAA111 BBB222", 5},
+		{CarQuery{Available: true, Limit: 2, Offset: 1}, "CCC333 EEE555", 3},
+		{CarQuery{Available: true, Offset: 2}, "EEE555", 3},
+		{CarQuery{Offset: 10}, "", 4},
+		{CarQuery{After: "BBB222", Limit: 1}, "CCC333", 4},
+		{CarQuery{Available: true, After: "CCC333"}, "EEE555", 3},
+	}
+	for _, tt := range tests {
+		cars, err := repo.List(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("list %+v: %v", tt.q, err)
+		}
+		var got []string
+		for _, car := range cars {
+			got = append(got, car.Registration)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("list %+v = %v, want %s", tt.q, got, tt.want)
+		}
+		if n, err := repo.Count(ctx, tt.q); err != nil || n != tt.n {
+			t.Errorf("count %+v = %d, %v, want %d", tt.q, n, err, tt.n)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// Package store persists cars.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Car represents a car entity.
+type Car struct {
+	Model        string `json:"model"`
+	Registration string `json:"registration"`
+	Mileage      int    `json:"mileage"`
+	Rented       bool   `json:"rented"`
+	// NeedsCleaning is set from a return until its cleaning task is
+	// completed. The car cannot be rented meanwhile.
+	NeedsCleaning bool `json:"needs_cleaning"`
+	// DeletedAt is set while the car is deleted. Deleted cars keep their
+	// history and can be restored.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// The vehicle details below are optional; zero values mean unknown.
+	Make  string `json:"make,omitempty"`
+	Year  int    `json:"year,omitempty"`
+	Color string `json:"color,omitempty"`
+	// VIN is the 17-character vehicle identification number.
+	VIN string `json:"vin,omitempty"`
+	// Category is the name of the Category the car is booked as.
+	Category string `json:"category,omitempty"`
+	// Transmission is manual or automatic.
+	Transmission string `json:"transmission,omitempty"`
+	// Fuel is petrol, diesel, hybrid or electric.
+	Fuel  string `json:"fuel,omitempty"`
+	Seats int    `json:"seats,omitempty"`
+
+	// Version goes up with every write to the car. It is not part of the
+	// car's JSON; the API sends it as the ETag instead.
+	Version int64 `json:"-"`
+}
+
+// CarQuery selects the cars returned by CarRepository.List.
+type CarQuery struct {
+	// Available restricts the result to cars that can be rented: neither
+	// rented nor waiting to be cleaned.
+	Available bool
+	// IncludeDeleted also returns deleted cars, whether or not Available
+	// is set.
+	IncludeDeleted bool
+	// Offset skips that many cars and Limit, if positive, caps how many
+	// are returned. Cars are ordered by registration so that pages do not
+	// overlap.
+	Limit  int
+	Offset int
+	// After, if set, starts the listing after the car with that
+	// registration. Unlike Offset it does not shift when cars are added
+	// or removed in front of the page. It is meant for the default Sort.
+	After string
+	// Rented, if set, keeps only cars that are, or are not, rented.
+	Rented *bool
+	// Model, if set, keeps only cars whose model contains it, ignoring
+	// case.
+	Model string
+	// MinMileage and MaxMileage, if set, bound the mileage of the cars,
+	// inclusively.
+	MinMileage *int
+	MaxMileage *int
+	// Sort orders the cars; the zero value orders them by registration.
+	// Cars that sort equal are ordered by registration.
+	Sort CarSort
+}
+
+// Fields a car listing can be sorted by.
+const (
+	SortRegistration = "registration"
+	SortModel        = "model"
+	SortMileage      = "mileage"
+)
+
+// CarSort is the order of a car listing.
+type CarSort struct {
+	// Field is one of the Sort constants; empty means SortRegistration.
+	Field string
+	Desc  bool
+}
+
+var (
+	ErrCarNotFound      = errors.New("car not found")
+	ErrCarAlreadyRented = errors.New("car is already rented")
+	ErrCarNotRented     = errors.New("car was not rented")
+	ErrCarNeedsCleaning = errors.New("car needs cleaning")
+	ErrCarNotDeleted    = errors.New("car is not deleted")
+	ErrCarModified      = errors.New("car was modified")
+)
+
+// CarRepository is the storage backend for cars.
+type CarRepository interface {
+	// List returns the cars selected by q.
+	List(ctx context.Context, q CarQuery) ([]Car, error)
+	// Count returns the number of cars selected by q, ignoring its Limit,
+	// Offset and After.
+	Count(ctx context.Context, q CarQuery) (int, error)
+	// Search returns up to limit cars, not deleted, in whose make, model,
+	// color or registration every word of query starts a word, best
+	// match first.
+	Search(ctx context.Context, query string, limit int) ([]Car, error)
+	// Get returns the car with the given registration, deleted or not, or
+	// ErrCarNotFound.
+	Get(ctx context.Context, registration string) (Car, error)
+	// CountRented returns the number of cars currently rented.
+	CountRented(ctx context.Context) (int, error)
+	// Add inserts a new car. It returns ErrCategoryNotFound if the car
+	// has a category that does not exist.
+	Add(ctx context.Context, car Car) error
+	// Update sets the model, mileage and vehicle details of the car with
+	// car.Registration. It returns ErrCarNotFound if there is no such car
+	// or it is deleted, and ErrCategoryNotFound like Add. A non-zero
+	// car.Version makes the update conditional: ErrCarModified means the
+	// car has another version.
+	Update(ctx context.Context, car Car) error
+	// MarkRented marks an available car as rented. It returns
+	// ErrCarNotFound, ErrCarAlreadyRented or ErrCarNeedsCleaning when the
+	// car cannot be rented.
+	MarkRented(ctx context.Context, registration string) error
+	// MarkReturned marks a rented car as no longer rented, adds the driven
+	// distance to its mileage and opens a cleaning task for it. It returns
+	// ErrCarNotFound or ErrCarNotRented when the car cannot be returned.
+	MarkReturned(ctx context.Context, registration string, drivenMileage int) error
+	// Delete marks a car deleted at time at. It returns ErrCarNotFound if
+	// there is no such car or it is already deleted, and
+	// ErrCarAlreadyRented while it is rented out. A non-zero version
+	// makes it conditional like Update.
+	Delete(ctx context.Context, registration string, version int64, at time.Time) error
+	// Restore undoes Delete. It returns ErrCarNotFound or
+	// ErrCarNotDeleted.
+	Restore(ctx context.Context, registration string) error
+}
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+	ErrCategoryInUse    = errors.New("category is in use")
+)
+
+// Category is a class of cars, such as economy or van. Customers book a
+// category rather than a particular car.
+type Category struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CategoryAvailability counts the cars of a category.
+type CategoryAvailability struct {
+	Category
+	// Available is the number of cars that can be rented now, Total that
+	// of all cars in the category that are not deleted.
+	Available int `json:"available"`
+	Total     int `json:"total"`
+}
+
+// CategoryRepository stores the car categories.
+type CategoryRepository interface {
+	// ListCategories returns every category ordered by name.
+	ListCategories(ctx context.Context) ([]Category, error)
+	// GetCategory returns the category with the given name or
+	// ErrCategoryNotFound.
+	GetCategory(ctx context.Context, name string) (Category, error)
+	// AddCategory inserts a new category. It returns ErrCategoryExists
+	// when the name is taken.
+	AddCategory(ctx context.Context, c Category) error
+	// UpdateCategory sets the description of a category or returns
+	// ErrCategoryNotFound.
+	UpdateCategory(ctx context.Context, c Category) error
+	// DeleteCategory removes a category. It returns ErrCategoryNotFound,
+	// or ErrCategoryInUse while any car, deleted or not, belongs to it.
+	DeleteCategory(ctx context.Context, name string) error
+	// CategoryAvailability counts the cars of every category, ordered by
+	// name. Cars without a category are not counted.
+	CategoryAvailability(ctx context.Context) ([]CategoryAvailability, error)
+}
+
+// Entity types and operations recorded in the change log.
+const (
+	EntityCar          = "car"
+	EntityKey          = "key"
+	EntityCleaningTask = "cleaning_task"
+	EntityCategory     = "category"
+
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Change is one entry in the change log. ID increases monotonically and is
+// used as the cursor for incremental reads.
+type Change struct {
+	ID         int64     `json:"id"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	Operation  string    `json:"operation"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// ChangeRepository reads the change log. Repositories append to it in the
+// same transaction as the write they record.
+type ChangeRepository interface {
+	// ListChanges returns up to limit changes with an ID greater than
+	// since, in ID order. Changes after a missing ID that may still be
+	// committed are held back, so a reader resuming after the last
+	// returned ID misses none.
+	ListChanges(ctx context.Context, since int64, limit int) ([]Change, error)
+	// LatestChangeID returns the ID of the newest change to an entity of
+	// the given type that ListChanges would return, or 0 if there is none.
+	LatestChangeID(ctx context.Context, entityType string) (int64, error)
+}
+
+// StatusRepository reports the health of the storage backend.
+type StatusRepository interface {
+	// Ping checks that the database is reachable.
+	Ping(ctx context.Context) error
+	// PendingMigrations returns the number of schema migrations not yet
+	// applied.
+	PendingMigrations(ctx context.Context) (int, error)
+}
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+)
+
+// User is an account that can log in to the API.
+type User struct {
+	Username     string
+	PasswordHash string
+	// Role is one of the roles defined by the service layer.
+	Role      string
+	CreatedAt time.Time
+	// Disabled users may not log in or refresh their tokens.
+	Disabled bool
+}
+
+// UserRepository stores user accounts.
+type UserRepository interface {
+	// GetUser returns the user with the given name or ErrUserNotFound.
+	GetUser(ctx context.Context, username string) (User, error)
+	// CreateUser inserts a new user. It returns ErrUserExists when the
+	// name is taken.
+	CreateUser(ctx context.Context, user User) error
+	// ListUsers returns every user ordered by name.
+	ListUsers(ctx context.Context) ([]User, error)
+	// SetUserRole changes the role of a user or returns ErrUserNotFound.
+	SetUserRole(ctx context.Context, username, role string) error
+	// DisableUser marks a user disabled and revokes all of their sessions
+	// in one transaction, returning how many sessions were still active.
+	// A name without an account but with sessions belongs to a user who
+	// logged in through OIDC; they get a placeholder account with an empty
+	// password hash to hold the flag. Any other unknown name is
+	// ErrUserNotFound.
+	DisableUser(ctx context.Context, username string, at time.Time) (int64, error)
+	// EnableUser clears the disabled flag of a user, deleting a placeholder
+	// account that only existed to hold it, or returns ErrUserNotFound.
+	EnableUser(ctx context.Context, username string) error
+}
+
+// DeprecatedUsage counts the requests one client made to a deprecated
+// surface of the API.
+type DeprecatedUsage struct {
+	Surface   string    `json:"surface"`
+	Client    string    `json:"client"`
+	Requests  int64     `json:"requests"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DeprecationRepository tracks who still uses deprecated endpoints and
+// fields.
+type DeprecationRepository interface {
+	// RecordDeprecatedUsage counts one request by client to surface at
+	// time at.
+	RecordDeprecatedUsage(ctx context.Context, surface, client string, at time.Time) error
+	// ListDeprecatedUsage returns all recorded usage ordered by surface
+	// and client.
+	ListDeprecatedUsage(ctx context.Context) ([]DeprecatedUsage, error)
+}
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a login that its refresh token can extend until it expires or
+// is revoked.
+type Session struct {
+	ID       string
+	Username string
+	// Role is the role granted when the session was last refreshed.
+	Role string
+	// RefreshTokenHash is the hex SHA-256 of the current refresh token.
+	RefreshTokenHash string
+	CreatedAt        time.Time
+	LastUsedAt       time.Time
+	ExpiresAt        time.Time
+	// RevokedAt is nil while the session is active.
+	RevokedAt *time.Time
+}
+
+// SessionRepository stores login sessions.
+type SessionRepository interface {
+	// CreateSession inserts a new session.
+	CreateSession(ctx context.Context, session Session) error
+	// GetSession returns the session with the given ID or
+	// ErrSessionNotFound.
+	GetSession(ctx context.Context, id string) (Session, error)
+	// RotateRefreshToken replaces the refresh token hash of an unrevoked
+	// session whose hash is still oldHash, and records the role and time
+	// of use. It returns ErrSessionNotFound if no such session exists, so
+	// of two concurrent refreshes with the same token only one succeeds.
+	RotateRefreshToken(ctx context.Context, id, oldHash, newHash, role string, at time.Time) error
+	// ListSessions returns the unrevoked sessions of a user, newest first.
+	ListSessions(ctx context.Context, username string) ([]Session, error)
+	// RevokeSession revokes an unrevoked session or returns
+	// ErrSessionNotFound.
+	RevokeSession(ctx context.Context, id string, at time.Time) error
+	// RevokeUserSessions revokes every session of a user and returns how
+	// many were still active.
+	RevokeUserSessions(ctx context.Context, username string, at time.Time) (int64, error)
+}
+
+// Kinds of car keys.
+const (
+	KeyPrimary = "primary"
+	KeySpare   = "spare"
+)
+
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyLocation is where a car key is: with a holder, in a lockbox slot, or
+// missing. Exactly one of them is set.
+type KeyLocation struct {
+	Holder  string `json:"holder,omitempty"`
+	Slot    string `json:"slot,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// CarKey is a physical key or fob of a car.
+type CarKey struct {
+	ID           string `json:"id"`
+	Registration string `json:"registration"`
+	Kind         string `json:"kind"`
+	KeyLocation
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KeyHandover records a key moving to a new location.
+type KeyHandover struct {
+	KeyID string `json:"key_id"`
+	KeyLocation
+	// RecordedBy is the user who recorded the handover.
+	RecordedBy string    `json:"recorded_by"`
+	At         time.Time `json:"at"`
+}
+
+// KeyRepository stores car keys and their handover log.
+type KeyRepository interface {
+	// AddKey inserts key and logs its location as the first handover. It
+	// returns ErrCarNotFound if the car does not exist.
+	AddKey(ctx context.Context, key CarKey, recordedBy string) error
+	// GetKey returns the key with the given ID or ErrKeyNotFound.
+	GetKey(ctx context.Context, id string) (CarKey, error)
+	// ListKeys returns the keys of the car with the given registration, or
+	// of every car if it is empty, ordered by registration and kind.
+	ListKeys(ctx context.Context, registration string) ([]CarKey, error)
+	// HandOver moves a key to the location of h and logs it. It returns
+	// ErrKeyNotFound if the key does not exist.
+	HandOver(ctx context.Context, h KeyHandover) error
+	// ListHandovers returns the handovers of a key, oldest first.
+	ListHandovers(ctx context.Context, keyID string) ([]KeyHandover, error)
+	// ListKeyAlerts returns the keys of cars in the fleet, deleted ones
+	// aside, that are missing or still with a holder while their car is
+	// not rented out, ordered like ListKeys.
+	ListKeyAlerts(ctx context.Context) ([]CarKey, error)
+	// CountKeyAlerts returns how many keys ListKeyAlerts would return.
+	CountKeyAlerts(ctx context.Context) (int, error)
+}
+
+var (
+	ErrCleaningTaskNotFound = errors.New("cleaning task not found")
+	ErrCleaningTaskDone     = errors.New("cleaning task is already completed")
+)
+
+// CleaningTask is the cleaning and detailing of a car after a return.
+type CleaningTask struct {
+	ID           int64  `json:"id"`
+	Registration string `json:"registration"`
+	// Assignee is the staff member doing the cleaning, or empty while
+	// the task is unassigned.
+	Assignee   string    `json:"assignee"`
+	ReturnedAt time.Time `json:"returned_at"`
+	// CleanedAt is nil while the task is open.
+	CleanedAt *time.Time `json:"cleaned_at,omitempty"`
+	CleanedBy string     `json:"cleaned_by,omitempty"`
+}
+
+// CleaningRepository stores the cleaning tasks opened by MarkReturned.
+type CleaningRepository interface {
+	// ListCleaningTasks returns the open cleaning tasks, oldest first.
+	ListCleaningTasks(ctx context.Context) ([]CleaningTask, error)
+	// GetCleaningTask returns the task with the given ID or
+	// ErrCleaningTaskNotFound.
+	GetCleaningTask(ctx context.Context, id int64) (CleaningTask, error)
+	// AssignCleaningTask sets the assignee of an open task. It returns
+	// ErrCleaningTaskNotFound or ErrCleaningTaskDone.
+	AssignCleaningTask(ctx context.Context, id int64, assignee string) error
+	// CompleteCleaningTask closes an open task and makes its car bookable
+	// again. It returns ErrCleaningTaskNotFound or ErrCleaningTaskDone.
+	CompleteCleaningTask(ctx context.Context, id int64, cleanedBy string, at time.Time) error
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+func (s *SQLRepository) GetUser(ctx context.Context, username string) (User, error) {
+	ctx, done := s.startOperation(ctx, "get_user")
+	defer done()
+
+	var u User
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("SELECT username, password_hash, role, created_at, disabled FROM users WHERE username = ?"), username).
+		Scan(&u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.Disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+func (s *SQLRepository) CreateUser(ctx context.Context, user User) error {
+	ctx, done := s.startOperation(ctx, "create_user")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// Checking inside the transaction gives a portable ErrUserExists
+		// instead of parsing each driver's unique-violation error.
+		var exists bool
+		err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)"), user.Username).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserExists
+		}
+		_, err = tx.ExecContext(ctx, s.dialect.rebind("INSERT INTO users (username, password_hash, role, created_at) VALUES (?, ?, ?, ?)"),
+			user.Username, user.PasswordHash, user.Role, user.CreatedAt)
+		return err
+	})
+}
+
+func (s *SQLRepository) ListUsers(ctx context.Context) ([]User, error) {
+	ctx, done := s.startOperation(ctx, "list_users")
+	defer done()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT username, password_hash, role, created_at, disabled FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.Disabled); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLRepository) SetUserRole(ctx context.Context, username, role string) error {
+	ctx, done := s.startOperation(ctx, "set_user_role")
+	defer done()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("UPDATE users SET role = ? WHERE username = ?"), role, username)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *SQLRepository) DisableUser(ctx context.Context, username string, at time.Time) (int64, error) {
+	ctx, done := s.startOperation(ctx, "disable_user")
+	defer done()
+
+	var revoked int64
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("UPDATE users SET disabled = ? WHERE username = ?"), true, username)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// Only OIDC logins start sessions without an account.
+			var oidc bool
+			err := tx.QueryRowContext(ctx, s.dialect.rebind("SELECT EXISTS(SELECT 1 FROM sessions WHERE username = ?)"), username).Scan(&oidc)
+			if err != nil {
+				return err
+			}
+			if !oidc {
+				return ErrUserNotFound
+			}
+			// No password hash matches an empty one, and the role is
+			// left to the column default.
+			_, err = tx.ExecContext(ctx, s.dialect.rebind("INSERT INTO users (username, password_hash, created_at, disabled) VALUES (?, '', ?, ?)"),
+				username, at, true)
+			if err != nil {
+				return err
+			}
+		}
+
+		res, err = tx.ExecContext(ctx, s.dialect.rebind("UPDATE sessions SET revoked_at = ? WHERE username = ? AND revoked_at IS NULL"), at, username)
+		if err != nil {
+			return err
+		}
+		revoked, err = res.RowsAffected()
+		return err
+	})
+	return revoked, err
+}
+
+func (s *SQLRepository) EnableUser(ctx context.Context, username string) error {
+	ctx, done := s.startOperation(ctx, "enable_user")
+	defer done()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// A placeholder would otherwise hand its default role to the
+		// user's next OIDC session on refresh.
+		res, err := tx.ExecContext(ctx, s.dialect.rebind("DELETE FROM users WHERE username = ? AND password_hash = ''"), username)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+		res, err = tx.ExecContext(ctx, s.dialect.rebind("UPDATE users SET disabled = ? WHERE username = ?"), false, username)
+		if err != nil {
+			return err
+		}
+		return requireRow(res, ErrUserNotFound)
+	})
+}
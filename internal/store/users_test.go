@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateAndGetUser(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	if _, err := repo.GetUser(ctx, "alice"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("get missing user: got %v, want %v", err, ErrUserNotFound)
+	}
+
+	user := User{Username: "alice", PasswordHash: "hash", Role: "agent", CreatedAt: time.Now().UTC()}
+	if err := repo.CreateUser(ctx, user); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.CreateUser(ctx, user); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("create twice: got %v, want %v", err, ErrUserExists)
+	}
+
+	got, err := repo.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Username != "alice" || got.PasswordHash != "hash" || got.Role != "agent" {
+		t.Fatalf("got %+v, want alice with the stored hash and role", got)
+	}
+}
+
+func TestListUsersAndSetRole(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, name := range []string{"bob", "alice"} {
+		if err := repo.CreateUser(ctx, User{Username: name, PasswordHash: "hash", Role: "customer", CreatedAt: time.Now().UTC()}); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	if err := repo.SetUserRole(ctx, "nobody", "admin"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("set role of missing user: got %v, want %v", err, ErrUserNotFound)
+	}
+	if err := repo.SetUserRole(ctx, "bob", "admin"); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+
+	users, err := repo.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].Username != "bob" || users[1].Role != "admin" {
+		t.Fatalf("got %+v, want alice then bob as admin", users)
+	}
+}
+
+func TestDisableAndEnableUser(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := repo.CreateUser(ctx, User{Username: "alice", PasswordHash: "hash", Role: "agent", CreatedAt: now}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, s := range []Session{{ID: "s1", Username: "alice"}, {ID: "s2", Username: "alice"}, {ID: "s3", Username: "oidc-bob"}} {
+		s.Role, s.RefreshTokenHash, s.CreatedAt, s.LastUsedAt, s.ExpiresAt = "agent", "hash", now, now, now.Add(time.Hour)
+		if err := repo.CreateSession(ctx, s); err != nil {
+			t.Fatalf("create session %s: %v", s.ID, err)
+		}
+	}
+	if err := repo.RevokeSession(ctx, "s1", now); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	n, err := repo.DisableUser(ctx, "alice", now)
+	if err != nil || n != 1 {
+		t.Fatalf("disable alice = %d, %v, want 1 session revoked", n, err)
+	}
+	if got, err := repo.GetUser(ctx, "alice"); err != nil || !got.Disabled || got.PasswordHash != "hash" {
+		t.Fatalf("alice = %+v, %v, want disabled with her hash kept", got, err)
+	}
+	if sessions, err := repo.ListSessions(ctx, "alice"); err != nil || len(sessions) != 0 {
+		t.Fatalf("alice's sessions = %+v, %v, want none", sessions, err)
+	}
+	if n, err := repo.DisableUser(ctx, "alice", now); err != nil || n != 0 {
+		t.Fatalf("disable alice again = %d, %v, want nothing revoked", n, err)
+	}
+
+	// A user known only to an OIDC provider gets a placeholder account.
+	if n, err := repo.DisableUser(ctx, "oidc-bob", now); err != nil || n != 1 {
+		t.Fatalf("disable oidc-bob = %d, %v, want 1 session revoked", n, err)
+	}
+	if got, err := repo.GetUser(ctx, "oidc-bob"); err != nil || !got.Disabled || got.PasswordHash != "" || got.Role != "customer" {
+		t.Fatalf("oidc-bob = %+v, %v, want a disabled customer without a password", got, err)
+	}
+	// Any other unknown name is an error, not a new account.
+	if _, err := repo.DisableUser(ctx, "nobody", now); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("disable unknown user: got %v, want %v", err, ErrUserNotFound)
+	}
+	if _, err := repo.GetUser(ctx, "nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("get unknown user after disabling: got %v, want %v", err, ErrUserNotFound)
+	}
+
+	if err := repo.EnableUser(ctx, "alice"); err != nil {
+		t.Fatalf("enable alice: %v", err)
+	}
+	if got, err := repo.GetUser(ctx, "alice"); err != nil || got.Disabled {
+		t.Fatalf("alice = %+v, %v, want enabled", got, err)
+	}
+	if err := repo.EnableUser(ctx, "oidc-bob"); err != nil {
+		t.Fatalf("enable oidc-bob: %v", err)
+	}
+	if _, err := repo.GetUser(ctx, "oidc-bob"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("oidc-bob after enabling: got %v, want the placeholder gone", err)
+	}
+	if err := repo.EnableUser(ctx, "nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("enable unknown user: got %v, want %v", err, ErrUserNotFound)
+	}
+}
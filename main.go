@@ -1,208 +1,222 @@
-package main
-
-import (
-	"database/sql"
-	"encoding/json"
-	"log"
-	"net/http"
-	"strconv"
-	"sync"
-
-	_ "github.com/glebarez/sqlite"
-	"github.com/gorilla/mux"
-)
-
-// Car represents a car entity.
-type Car struct {
-	Model        string `json:"model"`
-	Registration string `json:"registration"`
-	Mileage      int    `json:"mileage"`
-	Rented       bool   `json:"rented"`
-}
-
-var (
-	cars     []Car
-	carsLock sync.RWMutex
-	db       *sql.DB
-)
-
-func main() {
-	var err error
-	db, err = sql.Open("sqlite", "cars.db")
-	if err != nil {
-		log.Fatal("Error opening database:", err)
-	}
-	defer db.Close()
-
-	// Create table
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS cars (
-		model TEXT,
-		registration TEXT PRIMARY KEY,
-		mileage INTEGER,
-		rented BOOLEAN
-	)`)
-	if err != nil {
-		log.Fatal("Error creating table:", err)
-	}
-
-	// Insert mock data
-	_, err = db.Exec(`INSERT INTO cars (model, registration, mileage, rented)
-		VALUES ('Tesla M3', 'BTS812', 6003, 0)`)
-	if err != nil {
-		log.Fatal("Error inserting data:", err)
-	}
-
-	r := mux.NewRouter()
-
-	r.HandleFunc("/cars", listAvailableCars).Methods("GET")
-	r.HandleFunc("/cars", addCar).Methods("POST")
-	r.HandleFunc("/cars/{registration}/rentals", rentCar).Methods("POST")
-	r.HandleFunc("/cars/{registration}/returns", returnCar).Methods("POST")
-
-	log.Fatal(http.ListenAndServe(":8080", r))
-}
-
-func listAvailableCars(w http.ResponseWriter, r *http.Request) {
-	carsLock.RLock()
-	defer carsLock.RUnlock()
-
-	// Query data from database
-	rows, err := db.Query("SELECT model, registration, mileage, rented FROM cars")
-	if err != nil {
-		log.Printf("Error querying data: %v", err)                                         // Log detailed error information
-		http.Error(w, "Failed to retrieve available cars", http.StatusInternalServerError) // Return appropriate HTTP status code
-		return
-	}
-	defer rows.Close()
-
-	var availableCars []Car
-	for rows.Next() {
-		var car Car
-		err := rows.Scan(&car.Model, &car.Registration, &car.Mileage, &car.Rented)
-		if err != nil {
-			log.Printf("Error scanning row: %v", err)                                   // Log detailed error information
-			http.Error(w, "Failed to process car data", http.StatusInternalServerError) // Return appropriate HTTP status code
-			return
-		}
-		if !car.Rented {
-			availableCars = append(availableCars, car)
-		}
-	}
-
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(availableCars); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)                             // Log detailed error information
-		http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError) // Return appropriate HTTP status code
-		return
-	}
-}
-
-func addCar(w http.ResponseWriter, r *http.Request) {
-	var newCar Car
-	err := json.NewDecoder(r.Body).Decode(&newCar)
-	if err != nil {
-		log.Printf("Error decoding JSON request: %v", err)           // Log detailed error information
-		http.Error(w, "Invalid request body", http.StatusBadRequest) // Return appropriate HTTP status code
-		return
-	}
-
-	// Insert new car into database
-	_, err = db.Exec(`INSERT INTO cars (model, registration, mileage, rented)
-        VALUES (?, ?, ?, ?)`, newCar.Model, newCar.Registration, newCar.Mileage, newCar.Rented)
-	if err != nil {
-		log.Printf("Error inserting data: %v", err)                        // Log detailed error information
-		http.Error(w, "Failed to add car", http.StatusInternalServerError) // Return appropriate HTTP status code
-		return
-	}
-
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{"message": "Car added successfully"}); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)                             // Log detailed error information
-		http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError) // Return appropriate HTTP status code
-		return
-	}
-}
-
-func rentCar(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	registration := params["registration"]
-
-	carsLock.Lock()
-	defer carsLock.Unlock()
-
-	for i := range cars {
-		if cars[i].Registration == registration {
-			if cars[i].Rented {
-				log.Printf("Car %s is already rented", registration)          // Log detailed error information
-				http.Error(w, "Car is already rented", http.StatusBadRequest) // Return appropriate HTTP status code
-				return
-			}
-			cars[i].Rented = true
-			_, err := db.Exec("UPDATE cars SET rented = true WHERE registration = ?", registration)
-			if err != nil {
-				log.Printf("Error updating database: %v", err)                                      // Log detailed error information
-				http.Error(w, "Failed to update car rental status", http.StatusInternalServerError) // Return appropriate HTTP status code
-				return
-			}
-			if err := json.NewEncoder(w).Encode(map[string]interface{}{"message": "Car rented successfully"}); err != nil {
-				log.Printf("Error encoding JSON response: %v", err)                             // Log detailed error information
-				http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError) // Return appropriate HTTP status code
-				return
-			}
-			return
-		}
-	}
-
-	log.Printf("Car %s not found !!--", registration)    // Log detailed error information
-	http.Error(w, "Car not found ", http.StatusNotFound) // Return appropriate HTTP status code
-}
-
-func returnCar(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	registration := params["registration"]
-
-	carsLock.Lock()
-	defer carsLock.Unlock()
-
-	for i := range cars {
-		if cars[i].Registration == registration {
-			if !cars[i].Rented {
-				log.Printf("Car %s was not rented", registration)          // Log detailed error information
-				http.Error(w, "Car was not rented", http.StatusBadRequest) // Return appropriate HTTP status code
-				return
-			}
-			// If there's a mileage parameter in the request, update the car's mileage
-			if mileageStr := r.URL.Query().Get("mileage"); mileageStr != "" {
-				mileage, err := strconv.Atoi(mileageStr)
-				if err != nil {
-					log.Printf("Invalid mileage: %v", err)                  // Log detailed error information
-					http.Error(w, "Invalid mileage", http.StatusBadRequest) // Return appropriate HTTP status code
-					return
-				}
-				cars[i].Mileage += mileage
-				_, err = db.Exec("UPDATE cars SET rented = false, mileage = ? WHERE registration = ?", cars[i].Mileage, registration)
-				if err != nil {
-					log.Printf("Error updating database: %v", err)                             // Log detailed error information
-					http.Error(w, "Failed to update car data", http.StatusInternalServerError) // Return appropriate HTTP status code
-					return
-				}
-			} else {
-				cars[i].Rented = false
-				_, err := db.Exec("UPDATE cars SET rented = false WHERE registration = ?", registration)
-				if err != nil {
-					log.Printf("Error updating database: %v", err)                                      // Log detailed error information
-					http.Error(w, "Failed to update car rental status", http.StatusInternalServerError) // Return appropriate HTTP status code
-					return
-				}
-			}
-			if err := json.NewEncoder(w).Encode(map[string]interface{}{"message": "Car returned successfully"}); err != nil {
-				log.Printf("Error encoding JSON response: %v", err)                             // Log detailed error information
-				http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError) // Return appropriate HTTP status code
-				return
-			}
-			return
-		}
-	}
-
-	log.Printf("Car %s not found", registration)         // Log detailed error information
-	http.Error(w, "Car not found ", http.StatusNotFound) // Return appropriate HTTP status code
-}
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"backendGo/internal/api"
+	"backendGo/internal/config"
+	"backendGo/internal/metrics"
+	"backendGo/internal/service"
+	"backendGo/internal/store"
+	"backendGo/internal/tracing"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("Exiting", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	migrate := flag.String("migrate", "", "run migrations and exit: up applies all pending migrations, down rolls back the latest one")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		return fmt.Errorf("loading configuration: %w", err)
+	}
+	level, _ := cfg.SlogLevel()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	// Cancelled on SIGINT or SIGTERM; startup work and the server both stop
+	// when it is done.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: cfg.Tracing.ServiceName,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+	defer func() {
+		// Flush buffered spans even though ctx is already cancelled.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("Error flushing traces", "error", err)
+		}
+	}()
+
+	cars, err := store.Open(store.Config{
+		Driver:          cfg.Database.Driver,
+		DSN:             cfg.Database.DSN,
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer cars.Close()
+
+	switch *migrate {
+	case "":
+	case "up":
+		if err := cars.Migrate(ctx); err != nil {
+			return fmt.Errorf("applying migrations: %w", err)
+		}
+		return nil
+	case "down":
+		if err := cars.MigrateDown(ctx); err != nil {
+			return fmt.Errorf("rolling back migration: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid -migrate value %q: want up or down", *migrate)
+	}
+
+	// Apply pending migrations
+	if err := cars.Migrate(ctx); err != nil {
+		return fmt.Errorf("applying migrations: %w", err)
+	}
+
+	// Insert mock data
+	if err := cars.Seed(ctx); err != nil {
+		return fmt.Errorf("inserting data: %w", err)
+	}
+
+	rentals := service.NewRentalService(cars)
+	metrics.RegisterActiveRentals(rentals.ActiveRentals)
+	keys := service.NewKeyService(cars, cars)
+	metrics.RegisterKeyAlerts(keys.AlertCount)
+
+	cleaning := service.NewCleaningService(cars, time.Duration(cfg.Cleaning.TurnaroundSLA))
+	metrics.RegisterOverdueCleaning(func(ctx context.Context) (int, error) {
+		tasks, err := cleaning.Tasks(ctx)
+		n := 0
+		for _, task := range tasks {
+			if task.Overdue {
+				n++
+			}
+		}
+		return n, err
+	})
+
+	signingKey := []byte(cfg.Auth.JWTSigningKey)
+	if len(signingKey) == 0 {
+		slog.Warn("No JWT signing key configured, generating one; issued tokens will not survive a restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return fmt.Errorf("generating JWT signing key: %w", err)
+		}
+	}
+	auth := service.NewAuthService(cars, cars, service.AuthConfig{
+		SigningKey: signingKey,
+		TokenTTL:   time.Duration(cfg.Auth.TokenTTL),
+		RefreshTTL: time.Duration(cfg.Auth.RefreshTokenTTL),
+	})
+	if cfg.Auth.BootstrapUser != "" {
+		err := auth.CreateUser(ctx, cfg.Auth.BootstrapUser, cfg.Auth.BootstrapPassword, service.RoleAdmin)
+		switch {
+		case errors.Is(err, service.ErrUserExists):
+		case err != nil:
+			return fmt.Errorf("creating bootstrap user: %w", err)
+		default:
+			slog.Info("Created bootstrap user", "username", cfg.Auth.BootstrapUser)
+		}
+	}
+
+	var oidcLogin service.OIDCService
+	if cfg.OIDC.IssuerURL != "" {
+		groupRoles := make(map[string]service.Role, len(cfg.OIDC.GroupRoles))
+		for group, role := range cfg.OIDC.GroupRoles {
+			groupRoles[group] = service.Role(role)
+		}
+		oidcLogin, err = service.NewOIDCService(ctx, service.OIDCConfig{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			GroupsClaim:  cfg.OIDC.GroupsClaim,
+			GroupRoles:   groupRoles,
+		})
+		if err != nil {
+			return fmt.Errorf("setting up OIDC login: %w", err)
+		}
+	}
+
+	handler := api.NewHandler(api.Services{
+		Rentals:      rentals,
+		Changes:      service.NewChangeService(cars),
+		Health:       service.NewHealthService(cars),
+		Auth:         auth,
+		Keys:         keys,
+		Cleaning:     cleaning,
+		Categories:   service.NewCategoryService(cars),
+		OIDC:         oidcLogin,
+		Deprecations: service.NewDeprecationService(cars),
+	}, api.Config{
+		RateLimit: api.RateLimit{
+			RequestsPerSecond: cfg.RateLimit.RequestsPerSecond,
+			Burst:             cfg.RateLimit.Burst,
+			ClientIPHeader:    cfg.RateLimit.ClientIPHeader,
+		},
+		HandlerTimeout: time.Duration(cfg.Server.HandlerTimeout),
+		MaxBodyBytes:   cfg.Server.MaxBodyBytes,
+		CORS: api.CORS{
+			AllowedOrigins: cfg.CORS.AllowedOrigins,
+			AllowedMethods: cfg.CORS.AllowedMethods,
+			AllowedHeaders: cfg.CORS.AllowedHeaders,
+			MaxAge:         time.Duration(cfg.CORS.MaxAge),
+		},
+	})
+
+	server := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           handler.Router(),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+	server.RegisterOnShutdown(handler.Drain)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", cfg.ListenAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+	// A second signal kills the process instead of waiting for the drain.
+	stop()
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", time.Duration(cfg.Server.ShutdownTimeout).String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down server: %w", err)
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}